@@ -0,0 +1,78 @@
+package statetrc
+
+import (
+	"sort"
+	"time"
+)
+
+// PrefixStat summarizes the entries under an id prefix.
+type PrefixStat struct {
+	// Prefix of the ids of the entries
+	Prefix string
+	// Number of entries under the prefix
+	Count int
+	// Age of the oldest entry under the prefix
+	Oldest time.Duration
+	// Age of the newest entry under the prefix
+	Newest time.Duration
+}
+
+// Aggregate groups the entries of the Snapshot by the first depth elements of their ids and
+// returns statistics for each group, ordered by Prefix. For example at depth 1 the entries
+// "/conn/1" and "/conn/2" are counted in the group "/conn". Ages are relative to the time the
+// Snapshot was captured.
+func (s Snapshot) Aggregate(depth int) []PrefixStat {
+	groups := map[string]*PrefixStat{}
+	for _, e := range s.Entries {
+		p := idPrefix(e.Id, depth)
+		age := e.Age(s.At)
+		g, ok := groups[p]
+		if !ok {
+			groups[p] = &PrefixStat{Prefix: p, Count: 1, Oldest: age, Newest: age}
+			continue
+		}
+		g.Count++
+		if age > g.Oldest {
+			g.Oldest = age
+		}
+		if age < g.Newest {
+			g.Newest = age
+		}
+	}
+
+	res := make([]PrefixStat, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, *g)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Prefix < res[j].Prefix })
+	return res
+}
+
+// Aggregate groups the currently existing entries by prefix. See Snapshot.Aggregate.
+func (t *Tracer) Aggregate(depth int) []PrefixStat {
+	return t.Capture(nil).Aggregate(depth)
+}
+
+// Aggregate calls Aggregate on the default Tracer.
+func Aggregate(depth int) []PrefixStat {
+	return std.Aggregate(depth)
+}
+
+// idPrefix returns the prefix of id made of its first depth non-empty elements.
+// If id has fewer elements, id itself is returned.
+func idPrefix(id string, depth int) string {
+	if depth <= 0 {
+		return ""
+	}
+	n := 0
+	for i := 0; i < len(id); i++ {
+		if id[i] != '/' || i == 0 || id[i-1] == '/' {
+			continue
+		}
+		n++
+		if n == depth {
+			return id[:i]
+		}
+	}
+	return id
+}
@@ -0,0 +1,165 @@
+package statetrc
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultAnomalyFactor is the factor used by AnomalyHandler when none is requested, and by the
+// exporters that report anomalies.
+const DefaultAnomalyFactor = 3
+
+// anomalyMinCount is the number of completed entries a group needs before its active entries
+// are compared with them, so that a few samples do not cause false alarms.
+const anomalyMinCount = 10
+
+// Anomaly is an active entry that has been in its state unusually long compared with the
+// completed entries of its group.
+type Anomaly struct {
+	Entry Entry
+	// Group is the group of the statistics of the entry, as set by SetStatsDepth
+	Group string
+	// Age of the entry when it was detected
+	Age time.Duration
+	// Usual is the P99 of the durations of the group, or their Max if histograms are not
+	// enabled with SetHistogramBuckets
+	Usual time.Duration
+}
+
+func (a Anomaly) String() string {
+	return fmt.Sprintf("%s: %v, usually %v", a.Entry.Id, a.Age.Round(time.Millisecond), a.Usual)
+}
+
+// Anomalies returns the active entries whose age is more than factor times the usual duration
+// of their group, as kept by the statistics enabled with SetStatsDepth, oldest first. This finds
+// unusually slow states that a fixed threshold misses. Groups with fewer than 10 completed
+// entries are not checked.
+func (t *Tracer) Anomalies(factor float64) []Anomaly {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	t.expireStatsLocked()
+	if len(t.stats) == 0 {
+		return nil
+	}
+	now := t.nowLocked()
+
+	limits := map[string]time.Duration{}
+	var res []Anomaly
+	for _, e := range t.entries {
+		if !t.inScope(e.Id) {
+			continue
+		}
+		key := t.statsKeyLocked(e.Id)
+		s, ok := t.stats[key]
+		if !ok || s.Count < anomalyMinCount {
+			continue
+		}
+		usual, ok := limits[key]
+		if !ok {
+			usual = s.Max
+			if s.hist.Count > 0 {
+				usual = s.hist.Quantile(0.99)
+			}
+			limits[key] = usual
+		}
+		if age := e.Age(now); float64(age) > factor*float64(usual) {
+			res = append(res, Anomaly{Entry: t.redactLocked(e), Group: key, Age: age, Usual: usual})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Age > res[j].Age })
+	return res
+}
+
+// Anomalies calls Anomalies on the default Tracer.
+func Anomalies(factor float64) []Anomaly {
+	return std.Anomalies(factor)
+}
+
+// StartAnomalyDetector starts a goroutine that checks for Anomalies with factor every interval
+// and passes each new one to report, so that alerts or logs can be attached to them. Each entry
+// is reported once. If report is nil the anomalies are written to the standard logger. If
+// interval is zero or less 10 seconds is used. It returns a function that stops the detector.
+func (t *Tracer) StartAnomalyDetector(interval time.Duration, factor float64, report func(a Anomaly)) func() {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	if report == nil {
+		report = func(a Anomaly) {
+			log.Printf("statetrc: unusually slow entry %v", a)
+		}
+	}
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+		reported := map[uint64]bool{}
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			cur := map[uint64]bool{}
+			for _, a := range t.Anomalies(factor) {
+				cur[a.Entry.Seq] = true
+				if !reported[a.Entry.Seq] {
+					report(a)
+				}
+			}
+			reported = cur
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// StartAnomalyDetector calls StartAnomalyDetector on the default Tracer.
+func StartAnomalyDetector(interval time.Duration, factor float64, report func(a Anomaly)) func() {
+	return std.StartAnomalyDetector(interval, factor, report)
+}
+
+// AnomalyHandler returns an http.Handler that serves the entries reported by Anomalies, oldest
+// first, in the format selected as for Handler. The factor parameter overrides factor, which
+// is DefaultAnomalyFactor if zero.
+func (t *Tracer) AnomalyHandler(factor float64) http.Handler {
+	if factor == 0 {
+		factor = DefaultAnomalyFactor
+	}
+	return t.authorize(func(w http.ResponseWriter, r *http.Request) {
+		f, ctype, err := requestFormatter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		factor := factor
+		if v := r.URL.Query().Get("factor"); v != "" {
+			if factor, err = strconv.ParseFloat(v, 64); err != nil || factor <= 0 {
+				http.Error(w, fmt.Sprintf("statetrc: bad factor %q", v), http.StatusBadRequest)
+				return
+			}
+		}
+
+		s := Snapshot{At: t.Now()}
+		for _, a := range t.Anomalies(factor) {
+			s.Entries = append(s.Entries, a.Entry)
+		}
+		w.Header().Set("Content-Type", ctype)
+		w.Header().Set("Cache-Control", "no-cache")
+		s.Render(w, f)
+	})
+}
+
+// AnomalyHandler calls AnomalyHandler on the default Tracer.
+func AnomalyHandler(factor float64) http.Handler {
+	return std.AnomalyHandler(factor)
+}
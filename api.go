@@ -0,0 +1,111 @@
+package statetrc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// apiEntries is the response of APIHandler without group-by.
+type apiEntries struct {
+	At      time.Time   `json:"at"`
+	Total   int         `json:"total"`
+	Entries []jsonEntry `json:"entries"`
+}
+
+// apiGroups is the response of APIHandler with group-by.
+type apiGroups struct {
+	At     time.Time  `json:"at"`
+	Total  int        `json:"total"`
+	Groups []apiGroup `json:"groups"`
+}
+
+// apiGroup is the JSON form of a PrefixStat.
+type apiGroup struct {
+	Prefix   string `json:"prefix"`
+	Count    int    `json:"count"`
+	Oldest   string `json:"oldest"`
+	OldestNs int64  `json:"oldest_ns"`
+	Newest   string `json:"newest"`
+	NewestNs int64  `json:"newest_ns"`
+}
+
+// APIHandler returns an http.Handler that serves the entries as structured JSON for dashboards,
+// so that they need not download all the entries to show one subsystem. Entries are selected
+// with the URL query parameters described for Handler, except that offset and limit apply to
+// the groups if there are any, and with
+//
+//	group-by=N     return statistics for the groups of entries as by Aggregate(N)
+//
+// The response is an object with the time "at", the number of selected entries or groups
+// before offset and limit are applied as "total", and either the "entries" encoded as by
+// Entry.MarshalJSON, or with group-by the "groups", ordered by prefix, with their "prefix",
+// "count" and the ages of their "oldest" and "newest" entries.
+func (t *Tracer) APIHandler() http.Handler {
+	return t.authorize(func(w http.ResponseWriter, r *http.Request) {
+		q, depth, err := parseAPIParams(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		offset, limit := q.offset, q.limit
+		q.offset, q.limit = 0, 0
+		s := t.runQuery(q)
+
+		var res interface{}
+		if depth == 0 {
+			res = apiEntries{
+				At:      s.At,
+				Total:   len(s.Entries),
+				Entries: toJSONEntries(page(s.Entries, offset, limit), s.At),
+			}
+		} else {
+			stats := s.Aggregate(depth)
+			g := apiGroups{At: s.At, Total: len(stats), Groups: []apiGroup{}}
+			for _, p := range page(stats, offset, limit) {
+				g.Groups = append(g.Groups, apiGroup{
+					Prefix:   p.Prefix,
+					Count:    p.Count,
+					Oldest:   p.Oldest.String(),
+					OldestNs: int64(p.Oldest),
+					Newest:   p.Newest.String(),
+					NewestNs: int64(p.Newest),
+				})
+			}
+			res = g
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(res)
+	})
+}
+
+// APIHandler calls APIHandler on the default Tracer.
+func APIHandler() http.Handler {
+	return std.APIHandler()
+}
+
+// parseAPIParams returns the query and the group-by depth selected by the URL query parameters
+// described for APIHandler.
+func parseAPIParams(params url.Values) (*query, int, error) {
+	depth := 0
+	if vals, ok := params["group-by"]; ok {
+		d, err := strconv.Atoi(vals[0])
+		if err != nil || d < 0 {
+			return nil, 0, fmt.Errorf("statetrc: bad parameter group-by=%q: expected a depth", vals[0])
+		}
+		depth = d
+		delete(params, "group-by")
+	}
+	q, err := parseQueryParams(params)
+	if err != nil {
+		return nil, 0, err
+	}
+	return q, depth, nil
+}
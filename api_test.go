@@ -0,0 +1,59 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAPIHandler(t *testing.T) {
+	tests := []struct {
+		target string
+		total  int
+		want   []string
+	}{
+		{"/", 4, []string{"/conn/1", "/conn/2", "/conn/3/read", "/job"}},
+		{"/?prefix=/conn&min-age=30s", 2, []string{"/conn/1", "/conn/2"}},
+		{"/?order=-id&offset=1&limit=2", 4, []string{"/conn/3/read", "/conn/2"}},
+		{"/?label.peer=a", 1, []string{"/conn/1"}},
+		{"/?group-by=1", 2, []string{"/conn", "/job"}},
+		{"/?group-by=2&limit=1", 4, []string{"/conn/1"}},
+		{"/?q=prefix=/conn+limit=1", 3, []string{"/conn/1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := get(newQueryTracer().APIHandler(), tt.target)
+			if w.Code != 200 {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+			var res struct {
+				Total   int
+				Entries []struct{ Id string }
+				Groups  []struct{ Prefix string }
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, e := range res.Entries {
+				got = append(got, e.Id)
+			}
+			for _, g := range res.Groups {
+				got = append(got, g.Prefix)
+			}
+			if res.Total != tt.total || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %d %v, want %d %v", res.Total, got, tt.total, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIHandlerErrors(t *testing.T) {
+	for _, target := range []string{"/?group-by=x", "/?min-age=soon", "/?bogus=1"} {
+		if w := get(newQueryTracer().APIHandler(), target); w.Code != 400 {
+			t.Errorf("%s: status %d, want 400", target, w.Code)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package statetrc
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrUnauthorized is returned by an Authorizer to indicate that a request did not carry valid
+// credentials. The HTTP handlers respond to it with status 401 rather than 403.
+var ErrUnauthorized = errors.New("statetrc: unauthorized")
+
+// Authorizer decides whether a request may be served by the HTTP handlers of a Tracer, by
+// returning nil to allow it or an error to refuse it.
+type Authorizer func(r *http.Request) error
+
+// SetAuthorizer sets an Authorizer that is checked before every request is served by the HTTP
+// handlers of the Tracer and its namespaces, including those already created, since entries
+// can contain sensitive operational data and debug ports are often shared. Requests it
+// refuses get status 401 if the error is ErrUnauthorized, and otherwise status 403 with the
+// error as the body. Passing nil removes the Authorizer.
+func (t *Tracer) SetAuthorizer(a Authorizer) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.auth = a
+}
+
+// SetAuthorizer calls SetAuthorizer on the default Tracer.
+func SetAuthorizer(a Authorizer) {
+	std.SetAuthorizer(a)
+}
+
+// TokenAuthorizer returns an Authorizer that allows requests whose Authorization header holds
+// the bearer token, as in "Authorization: Bearer <token>", and returns ErrUnauthorized for others.
+func TokenAuthorizer(token string) Authorizer {
+	return func(r *http.Request) error {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return ErrUnauthorized
+		}
+		return nil
+	}
+}
+
+// authorize returns a handler that serves requests with h if they are allowed by the Authorizer.
+func (t *Tracer) authorize(h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.mtx.Lock()
+		a := t.auth
+		t.mtx.Unlock()
+
+		if a != nil {
+			if err := a(r); errors.Is(err, ErrUnauthorized) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="statetrc"`)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			} else if err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+		h(w, r)
+	})
+}
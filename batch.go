@@ -0,0 +1,58 @@
+package statetrc
+
+import "fmt"
+
+// EnterBatch adds all the passed entries while locking the Tracer only once, which is cheaper
+// than calling Enter for each when registering many items of a set. The Id, Props, Labels and
+// Parent of each Entry are used as they would be by Enter. If the Time of an Entry is zero the
+// current time is used, and if its Expires time is set it expires as if created with WithTTL.
+func (t *Tracer) EnterBatch(entries []Entry) {
+	if compiledOut || t.disabled.Load() {
+		return
+	}
+	t.mtx.Lock()
+	t.expireLocked()
+
+	var errs []error
+	for _, n := range entries {
+		if !t.prepare(&n) {
+			continue
+		}
+		n.Labels = copyLabels(n.Labels)
+		n.Count = 0
+		if _, replaced := t.enterLocked(n); replaced && t.misuse != nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrExists, n.Id))
+		}
+	}
+	t.unlockAndReport(errs)
+}
+
+// EnterBatch calls EnterBatch on the default Tracer.
+func EnterBatch(entries []Entry) {
+	std.EnterBatch(entries)
+}
+
+// LeaveBatch leaves all the entries with the passed ids while locking the Tracer only once.
+func (t *Tracer) LeaveBatch(ids []string) {
+	if compiledOut || t.disabled.Load() {
+		return
+	}
+	t.mtx.Lock()
+
+	var errs []error
+	for _, id := range ids {
+		id = t.full(id)
+		if t.off(id) {
+			continue
+		}
+		if !t.leaveLocked(id, 0) && t.misuse != nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrNotFound, id))
+		}
+	}
+	t.unlockAndReport(errs)
+}
+
+// LeaveBatch calls LeaveBatch on the default Tracer.
+func LeaveBatch(ids []string) {
+	std.LeaveBatch(ids)
+}
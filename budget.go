@@ -0,0 +1,180 @@
+package statetrc
+
+import (
+	"sort"
+	"time"
+)
+
+// BudgetStatus reports the consumption of a time budget set by SetBudget.
+type BudgetStatus struct {
+	// Prefix of the ids of the entries the budget is for
+	Prefix string
+	// Limit is the time the entries may take
+	Limit time.Duration
+	// Used is the time during which there was at least one entry under Prefix since the
+	// budget was set, so that overlapping entries are only counted once
+	Used time.Duration
+	// Active is the number of entries under Prefix now
+	Active int
+	// Exceeded is the time at which Used exceeded Limit, or zero if it has not
+	Exceeded time.Time
+}
+
+// Remaining returns the time left of the budget, which is negative if it was exceeded.
+func (s BudgetStatus) Remaining() time.Duration {
+	return s.Limit - s.Used
+}
+
+// budget is a budget set by SetBudget. used does not include the time since since if active
+// is not zero. timer calls checkBudget when the budget is due to be exceeded.
+type budget struct {
+	limit    time.Duration
+	fn       func(s BudgetStatus)
+	active   int
+	since    time.Time
+	used     time.Duration
+	exceeded time.Time
+	reported bool
+	timer    *time.Timer
+}
+
+// minBudgetCheck is the shortest interval at which a budget that is about to be exceeded is
+// checked, which matters if the clock set by SetClock does not advance.
+const minBudgetCheck = 10 * time.Millisecond
+
+// SetBudget declares that the entries under the path prefix must take at most limit in total,
+// counting the time during which at least one of them existed, such as all phases of startup
+// within 10 seconds with SetBudget("/startup", 10*time.Second, fn). This tracks startup phases
+// and batch pipelines. If fn is not nil it is called once by another goroutine when the budget
+// is exceeded, and a panic in it is handled as for OnEnter. Setting a budget for a prefix again
+// restarts it from the current entries, and passing a limit of zero removes it. See CountPrefix
+// for how prefixes are matched.
+func (t *Tracer) SetBudget(prefix string, limit time.Duration, fn func(s BudgetStatus)) {
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if b, ok := t.budgets[prefix]; ok {
+		if b.timer != nil {
+			b.timer.Stop()
+		}
+		delete(t.budgets, prefix)
+	}
+	if limit <= 0 {
+		return
+	}
+	if t.budgets == nil {
+		t.budgets = map[string]*budget{}
+	}
+	b := &budget{limit: limit, fn: fn}
+	t.budgets[prefix] = b
+	n := 0
+	for id := range t.entries {
+		if hasPathPrefix(id, prefix) {
+			n++
+		}
+	}
+	t.setBudgetActiveLocked(prefix, b, n, t.nowLocked())
+}
+
+// SetBudget calls SetBudget on the default Tracer.
+func SetBudget(prefix string, limit time.Duration, fn func(s BudgetStatus)) {
+	std.SetBudget(prefix, limit, fn)
+}
+
+// Budgets returns the status of the budgets set by SetBudget, ordered by Prefix.
+func (t *Tracer) Budgets() []BudgetStatus {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	now := t.nowLocked()
+	var res []BudgetStatus
+	for prefix, b := range t.budgets {
+		if t.inScope(prefix) {
+			res = append(res, b.status(prefix, now))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Prefix < res[j].Prefix })
+	return res
+}
+
+// Budgets calls Budgets on the default Tracer.
+func Budgets() []BudgetStatus {
+	return std.Budgets()
+}
+
+// status returns the status of b, which has the prefix prefix, at now. t.mtx must be held.
+func (b *budget) status(prefix string, now time.Time) BudgetStatus {
+	used := b.used
+	if b.active > 0 {
+		used += now.Sub(b.since)
+	}
+	if b.exceeded.IsZero() && used > b.limit {
+		b.exceeded = now.Add(b.limit - used)
+	}
+	return BudgetStatus{Prefix: prefix, Limit: b.limit, Used: used, Active: b.active, Exceeded: b.exceeded}
+}
+
+// countBudgetLocked adds delta to the number of entries of the budgets whose prefixes id is
+// under. t.mtx must be held.
+func (c *core) countBudgetLocked(id string, delta int, now time.Time) {
+	for prefix, b := range c.budgets {
+		if hasPathPrefix(id, prefix) {
+			c.setBudgetActiveLocked(prefix, b, b.active+delta, now)
+		}
+	}
+}
+
+// setBudgetActiveLocked sets the number of entries of the budget b for prefix to active at now,
+// and starts or stops its timer when it starts or stops being used. t.mtx must be held.
+func (c *core) setBudgetActiveLocked(prefix string, b *budget, active int, now time.Time) {
+	if b.active > 0 {
+		b.used += now.Sub(b.since)
+	}
+	was := b.active
+	b.active, b.since = active, now
+	if b.fn == nil || b.reported {
+		return
+	}
+	switch {
+	case was == 0 && active > 0:
+		c.startBudgetTimerLocked(prefix, b)
+	case was > 0 && active == 0 && b.timer != nil:
+		// If the budget was just exceeded, let the timer report it.
+		if b.used <= b.limit {
+			b.timer.Stop()
+		}
+		b.timer = nil
+	}
+}
+
+// startBudgetTimerLocked starts the timer of the budget b for prefix to fire when the budget is
+// due to be exceeded. t.mtx must be held.
+func (c *core) startBudgetTimerLocked(prefix string, b *budget) {
+	d := b.limit - b.used
+	if d < minBudgetCheck {
+		d = minBudgetCheck
+	}
+	b.timer = time.AfterFunc(d, func() { c.checkBudget(prefix, b) })
+}
+
+// checkBudget reports the budget b for prefix to its function if it has been exceeded, or
+// restarts its timer if it is still being used.
+func (c *core) checkBudget(prefix string, b *budget) {
+	c.mtx.Lock()
+	if c.budgets[prefix] != b || b.reported {
+		c.mtx.Unlock()
+		return
+	}
+	s := b.status(prefix, c.nowLocked())
+	if s.Exceeded.IsZero() {
+		if b.active > 0 {
+			c.startBudgetTimerLocked(prefix, b)
+		}
+		c.mtx.Unlock()
+		return
+	}
+	b.reported = true
+	fn, misuse := b.fn, c.misuse
+	c.mtx.Unlock()
+	runHook(misuse, func() { fn(s) })
+}
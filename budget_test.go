@@ -0,0 +1,61 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.SetBudget("/startup", 10*time.Second, nil)
+	tr.Enter("/startup/db", nil)
+	clock.Add(2 * time.Second)
+	// Overlapping entries are only counted once.
+	tr.Enter("/startup/cache", nil)
+	clock.Add(3 * time.Second)
+	tr.Leave("/startup/db")
+	tr.Leave("/startup/cache")
+	clock.Add(time.Hour)
+	tr.Enter("/startup/http", nil)
+	clock.Add(6 * time.Second)
+
+	b := tr.Budgets()
+	if len(b) != 1 {
+		t.Fatalf("got %d budgets, want 1", len(b))
+	}
+	want := BudgetStatus{
+		Prefix:   "/startup",
+		Limit:    10 * time.Second,
+		Used:     11 * time.Second,
+		Active:   1,
+		Exceeded: clock.Now().Add(-time.Second),
+	}
+	if b[0] != want {
+		t.Errorf("got %+v, want %+v", b[0], want)
+	}
+	if r := b[0].Remaining(); r != -time.Second {
+		t.Errorf("Remaining = %v, want -1s", r)
+	}
+
+	tr.SetBudget("/startup", 0, nil)
+	if b := tr.Budgets(); len(b) != 0 {
+		t.Errorf("after removing the budget got %v", b)
+	}
+}
+
+func TestBudgetExceeded(t *testing.T) {
+	tr := NewTracer()
+	exceeded := make(chan BudgetStatus, 1)
+	tr.SetBudget("/startup", 10*time.Millisecond, func(s BudgetStatus) { exceeded <- s })
+	tr.Enter("/startup/db", nil)
+	select {
+	case s := <-exceeded:
+		if s.Prefix != "/startup" || s.Exceeded.IsZero() {
+			t.Errorf("got %+v", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the budget was not reported")
+	}
+}
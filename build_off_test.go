@@ -0,0 +1,34 @@
+//go:build statetrc_off
+
+package statetrc
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCompiledOut(t *testing.T) {
+	tr := NewTracer()
+	var errs []error
+	tr.SetStrict(func(err error) { errs = append(errs, err) })
+
+	if r := tr.Enter("/a", nil); r != nil {
+		t.Error("Enter returned a Region")
+	}
+	r := tr.Enter("/b", nil)
+	r.Leave()
+	tr.Leave("/a")
+	tr.Update("/a", 1)
+	ctx := context.Background()
+	if got, leave := tr.EnterCtx(ctx, "/c", nil); got != ctx {
+		t.Error("EnterCtx returned a new context")
+	} else {
+		leave()
+	}
+	if n := tr.Count(); n != 0 {
+		t.Errorf("Count = %d, want 0", n)
+	}
+	if len(errs) > 0 {
+		t.Errorf("got errors %v", errs)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !statetrc_off
+
+package statetrc
+
+// compiledOut is true when the package is built with the statetrc_off build tag.
+const compiledOut = false
@@ -0,0 +1,86 @@
+package statetrc
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+// chromeEvent is an event in the Chrome trace event format.
+type chromeEvent struct {
+	Name string                 `json:"name"`
+	Cat  string                 `json:"cat"`
+	Ph   string                 `json:"ph"`
+	Ts   float64                `json:"ts"`
+	Dur  float64                `json:"dur"`
+	Pid  int                    `json:"pid"`
+	Tid  int                    `json:"tid"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the completed entries recorded since SetHistorySize was called, along
+// with the currently existing entries, to w in the Chrome trace event JSON format. The output
+// can be loaded into chrome://tracing or the Perfetto UI to explore the states on a timeline.
+// Active entries are shown as ending at the time the trace was written, with the arg "active".
+// Entries are placed on as few rows (threads) as possible such that entries on the same row
+// do not overlap.
+func (t *Tracer) WriteChromeTrace(w io.Writer) error {
+	s := t.Capture(nil)
+	l := append(t.completed(), s.Entries...)
+	sort.SliceStable(l, func(i, j int) bool { return l[i].Time.Before(l[j].Time) })
+
+	var ends []time.Time
+	events := make([]chromeEvent, 0, len(l))
+	for _, e := range l {
+		end := e.EndTime
+		if end.IsZero() {
+			end = s.At
+		}
+
+		// Use the first row whose last entry has ended.
+		tid := -1
+		for i, last := range ends {
+			if !last.After(e.Time) {
+				tid = i
+				break
+			}
+		}
+		if tid < 0 {
+			tid = len(ends)
+			ends = append(ends, time.Time{})
+		}
+		ends[tid] = end
+
+		ev := chromeEvent{
+			Name: e.Id,
+			Cat:  "statetrc",
+			Ph:   "X",
+			Ts:   float64(e.Time.UnixNano()) / 1e3,
+			Dur:  float64(end.Sub(e.Time)) / 1e3,
+			Pid:  1,
+			Tid:  tid + 1,
+		}
+		args := map[string]interface{}{}
+		if e.EndTime.IsZero() {
+			args["active"] = true
+		}
+		if p := propsString(e, &DefaultFormatOptions); p != "" {
+			args["props"] = p
+		}
+		if len(args) > 0 {
+			ev.Args = args
+		}
+		events = append(events, ev)
+	}
+
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents     []chromeEvent `json:"traceEvents"`
+		DisplayTimeUnit string        `json:"displayTimeUnit"`
+	}{events, "ms"})
+}
+
+// WriteChromeTrace calls WriteChromeTrace on the default Tracer.
+func WriteChromeTrace(w io.Writer) error {
+	return std.WriteChromeTrace(w)
+}
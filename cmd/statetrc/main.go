@@ -0,0 +1,281 @@
+// Command statetrc inspects the entries of a running process that serves them with package
+// httptrc, statetrc.ListenAndServeUnix or package grpctrc.
+//
+// Usage:
+//
+//	statetrc [-http URL | -unix PATH | -grpc ADDR] [-token TOKEN] COMMAND [FLAGS] [ARGS]
+//
+// The commands are:
+//
+//	list     print the entries
+//	tree     print the entries as a tree
+//	oldest   print a table of the oldest entries
+//	watch    print changes to the entries as they happen
+//	top      continuously show the entries, oldest first
+//	diff     print the entries added, removed and persisting over an interval, or
+//	         between two snapshots saved with list -format json
+//
+// The commands accept -prefix, -min-age and -max-age to select entries. Run
+// statetrc COMMAND -h for the flags of a command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/jeffwilliams/statetrc"
+)
+
+const usage = `usage: statetrc [-http URL | -unix PATH | -grpc ADDR] [-token TOKEN] COMMAND [FLAGS] [ARGS]
+
+Commands:
+  list     print the entries
+  tree     print the entries as a tree
+  oldest   print a table of the oldest entries
+  watch    print changes to the entries as they happen
+  top      continuously show the entries, oldest first
+  diff     print the entries added, removed and persisting over an interval,
+           or between two snapshots saved with list -format json
+
+Run statetrc COMMAND -h for the flags of a command.
+
+Global flags:
+`
+
+// errUsage reports a usage error that has already been described.
+var errUsage = errors.New("usage")
+
+func main() {
+	httpAddr := flag.String("http", "http://localhost:6060/debug/statetrc/", "`URL` of the statetrc HTTP handler")
+	unixPath := flag.String("unix", "", "`path` of the Unix socket served by ListenAndServeUnix")
+	grpcAddr := flag.String("grpc", "", "`address` of the grpctrc service")
+	token := flag.String("token", "", "bearer `token` sent to HTTP handlers protected by statetrc.TokenAuthorizer")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var src source
+	var err error
+	switch {
+	case *grpcAddr != "":
+		src, err = newGRPCSource(*grpcAddr)
+	case *unixPath != "":
+		src = newUnixSource(*unixPath, *token)
+	default:
+		src, err = newHTTPSource(*httpAddr, *token)
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	c, ok := commands[cmd]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "statetrc: unknown command %q\n", cmd)
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := c(ctx, src, args); err != nil {
+		if err == errUsage {
+			os.Exit(2)
+		}
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "statetrc: %v\n", err)
+	os.Exit(1)
+}
+
+var commands = map[string]func(ctx context.Context, src source, args []string) error{
+	"list":   list,
+	"tree":   tree,
+	"oldest": oldest,
+	"watch":  watch,
+	"top":    top,
+	"diff":   diff,
+}
+
+// newFlagSet returns a FlagSet for the command name with the flags selecting entries bound to f.
+func newFlagSet(name string, f *filter) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&f.prefix, "prefix", "", "only entries under the path `prefix`")
+	fs.DurationVar(&f.minAge, "min-age", 0, "only entries older than `duration`")
+	fs.DurationVar(&f.maxAge, "max-age", 0, "only entries younger than `duration`")
+	return fs
+}
+
+// parse parses args with fs, returning errUsage if they are invalid.
+func parse(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		return errUsage
+	}
+	if fs.NArg() > nargs {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %v\n", fs.Args()[nargs:])
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func list(ctx context.Context, src source, args []string) error {
+	var f filter
+	fs := newFlagSet("list", &f)
+	fs.StringVar(&f.order, "order", "", "`order` of the entries: id, age, newest, seq or prop:NAME, with - to reverse")
+	fs.IntVar(&f.limit, "limit", 0, "print at most `n` entries")
+	format := fs.String("format", "text", "output `format`: text, json, yaml, table, tree or markdown")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	formatter, ok := formatters[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	snap, err := src.snapshot(ctx, f)
+	if err != nil {
+		return err
+	}
+	return snap.Render(os.Stdout, formatter)
+}
+
+var formatters = map[string]statetrc.Formatter{
+	"text":     statetrc.TextFormatter{},
+	"json":     statetrc.JSONFormatter{Indent: "  "},
+	"yaml":     statetrc.YAMLFormatter{},
+	"table":    statetrc.TableFormatter{},
+	"tree":     statetrc.TreeFormatter{},
+	"markdown": statetrc.MarkdownFormatter{},
+}
+
+func tree(ctx context.Context, src source, args []string) error {
+	var f filter
+	fs := newFlagSet("tree", &f)
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	snap, err := src.snapshot(ctx, f)
+	if err != nil {
+		return err
+	}
+	return snap.Render(os.Stdout, statetrc.TreeFormatter{})
+}
+
+func oldest(ctx context.Context, src source, args []string) error {
+	f := filter{order: "age"}
+	fs := newFlagSet("oldest", &f)
+	fs.IntVar(&f.limit, "n", 10, "print the `n` oldest entries")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	snap, err := src.snapshot(ctx, f)
+	if err != nil {
+		return err
+	}
+	return snap.Render(os.Stdout, statetrc.TableFormatter{})
+}
+
+func watch(ctx context.Context, src source, args []string) error {
+	var f filter
+	fs := newFlagSet("watch", &f)
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	return src.watch(ctx, f.prefix, func(ev statetrc.Event) {
+		e := ev.Entry
+		age := e.Age(ev.Time)
+		if f.minAge > 0 && age <= f.minAge || f.maxAge > 0 && age >= f.maxAge {
+			return
+		}
+		line := fmt.Sprintf("%s %-6s %s", ev.Time.Format("15:04:05.000"), ev.Type, e.Id)
+		if ev.Type != statetrc.EnterEvent {
+			line += fmt.Sprintf(" (%v)", age)
+		}
+		if e.Props != nil {
+			line += fmt.Sprintf(": %v", e.Props)
+		}
+		fmt.Println(line)
+	})
+}
+
+func diff(ctx context.Context, src source, args []string) error {
+	var f filter
+	fs := newFlagSet("diff", &f)
+	interval := fs.Duration("interval", 10*time.Second, "time between the two snapshots")
+	all := fs.Bool("all", false, "also print the entries that persisted")
+	if err := parse(fs, args, 2); err != nil {
+		return err
+	}
+
+	var a, b statetrc.Snapshot
+	var err error
+	switch fs.NArg() {
+	case 0:
+		if a, err = src.snapshot(ctx, f); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+		if b, err = src.snapshot(ctx, f); err != nil {
+			return err
+		}
+	case 2:
+		if a, err = readSnapshot(fs.Arg(0)); err != nil {
+			return err
+		}
+		if b, err = readSnapshot(fs.Arg(1)); err != nil {
+			return err
+		}
+	default:
+		fmt.Fprintln(fs.Output(), "diff takes no arguments or two snapshot files")
+		return errUsage
+	}
+
+	added, removed, persisted := statetrc.Diff(a, b)
+	for _, e := range removed {
+		fmt.Printf("- %s (%v)\n", e.Id, e.Age(b.At))
+	}
+	for _, e := range added {
+		fmt.Printf("+ %s (%v)\n", e.Id, e.Age(b.At))
+	}
+	if *all {
+		for _, e := range persisted {
+			fmt.Printf("  %s (%v)\n", e.Id, e.Age(b.At))
+		}
+	}
+	fmt.Printf("%d added, %d removed, %d persisted\n", len(added), len(removed), len(persisted))
+	return nil
+}
+
+// readSnapshot reads a snapshot saved in JSON format.
+func readSnapshot(path string) (statetrc.Snapshot, error) {
+	var snap statetrc.Snapshot
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return snap, err
+	}
+	if err := snap.UnmarshalJSON(b); err != nil {
+		return snap, fmt.Errorf("%s: %w", path, err)
+	}
+	return snap, nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jeffwilliams/statetrc"
+	"github.com/jeffwilliams/statetrc/grpctrc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// filter selects the entries requested from a process.
+type filter struct {
+	prefix         string
+	minAge, maxAge time.Duration
+	order          string
+	limit          int
+}
+
+// query returns the filter as a statetrc.Query string.
+func (f filter) query() string {
+	var terms []string
+	if f.prefix != "" {
+		terms = append(terms, "prefix="+f.prefix)
+	}
+	if f.minAge > 0 {
+		terms = append(terms, "age>"+f.minAge.String())
+	}
+	if f.maxAge > 0 {
+		terms = append(terms, "age<"+f.maxAge.String())
+	}
+	if f.order != "" {
+		terms = append(terms, "order="+f.order)
+	}
+	if f.limit > 0 {
+		terms = append(terms, fmt.Sprintf("limit=%d", f.limit))
+	}
+	return strings.Join(terms, " ")
+}
+
+// params returns the filter as the URL query parameters of statetrc.Handler.
+func (f filter) params() url.Values {
+	v := url.Values{"format": {"json"}}
+	if f.prefix != "" {
+		v.Set("prefix", f.prefix)
+	}
+	if f.minAge > 0 {
+		v.Set("min-age", f.minAge.String())
+	}
+	if f.maxAge > 0 {
+		v.Set("max-age", f.maxAge.String())
+	}
+	if f.order != "" {
+		v.Set("order", f.order)
+	}
+	if f.limit > 0 {
+		v.Set("limit", fmt.Sprint(f.limit))
+	}
+	return v
+}
+
+// source is a process whose entries can be inspected.
+type source interface {
+	// snapshot returns the entries selected by f.
+	snapshot(ctx context.Context, f filter) (statetrc.Snapshot, error)
+	// watch calls fn with each change to the entries under prefix until ctx is done.
+	watch(ctx context.Context, prefix string, fn func(ev statetrc.Event)) error
+}
+
+// httpSource reads the entries from the handlers of statetrc.Handler and statetrc.SSEHandler,
+// as registered by package httptrc or served by statetrc.ListenAndServeUnix.
+type httpSource struct {
+	client *http.Client
+	// base is the URL of the Handler. The SSEHandler is at "events" relative to it.
+	base *url.URL
+	// token is sent as a bearer token if it is not empty
+	token string
+}
+
+func newHTTPSource(base, token string) (*httpSource, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return &httpSource{client: http.DefaultClient, base: u, token: token}, nil
+}
+
+func newUnixSource(path, token string) *httpSource {
+	tr := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}
+	return &httpSource{client: &http.Client{Transport: tr}, base: &url.URL{Scheme: "http", Host: "unix", Path: "/"}, token: token}
+}
+
+func (s *httpSource) get(ctx context.Context, path string, params url.Values) (*http.Response, error) {
+	u := s.base.ResolveReference(&url.URL{Path: path, RawQuery: params.Encode()})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s: %s", u, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return resp, nil
+}
+
+func (s *httpSource) snapshot(ctx context.Context, f filter) (statetrc.Snapshot, error) {
+	var snap statetrc.Snapshot
+	resp, err := s.get(ctx, "", f.params())
+	if err != nil {
+		return snap, err
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&snap)
+	return snap, err
+}
+
+func (s *httpSource) watch(ctx context.Context, prefix string, fn func(ev statetrc.Event)) error {
+	params := url.Values{}
+	if prefix != "" {
+		params.Set("prefix", prefix)
+	}
+	resp, err := s.get(ctx, "events", params)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Read the events, skipping the snapshots.
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, 16<<20)
+	event := ""
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = line[len("event: "):]
+		case strings.HasPrefix(line, "data: ") && event != "snapshot":
+			var ev statetrc.Event
+			if err := json.Unmarshal([]byte(line[len("data: "):]), &ev); err != nil {
+				return err
+			}
+			fn(ev)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return sc.Err()
+}
+
+// grpcSource reads the entries from the service of package grpctrc.
+type grpcSource struct {
+	client *grpctrc.Client
+}
+
+func newGRPCSource(addr string) (*grpcSource, error) {
+	cc, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &grpcSource{client: grpctrc.NewClient(cc)}, nil
+}
+
+func (s *grpcSource) snapshot(ctx context.Context, f filter) (statetrc.Snapshot, error) {
+	return s.client.ListEntries(ctx, f.query())
+}
+
+func (s *grpcSource) watch(ctx context.Context, prefix string, fn func(ev statetrc.Event)) error {
+	err := s.client.Watch(ctx, prefix, func(ev statetrc.Event) bool {
+		fn(ev)
+		return true
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeffwilliams/statetrc"
+	"golang.org/x/term"
+)
+
+// topOrders are the orders that the top command can sort by, by key.
+var topOrders = map[byte]struct {
+	name  string
+	order statetrc.Order
+}{
+	'a': {"age", statetrc.ByAge},
+	'n': {"newest", statetrc.ByNewest},
+	'i': {"id", statetrc.ById},
+	'c': {"count", byCount},
+}
+
+// byCount orders entries by Count, highest first, then by age.
+var byCount statetrc.Order = statetrc.Then(func(l []statetrc.Entry) func(i, j int) bool {
+	return func(i, j int) bool {
+		return l[i].Count > l[j].Count
+	}
+}, statetrc.ByAge)
+
+// top continuously shows the entries in a table, like top(1). When the standard input is a
+// terminal keys change the order: a for age, n for newest, i for id, c for count, r to reverse
+// and q to quit.
+func top(ctx context.Context, src source, args []string) error {
+	var f filter
+	fs := newFlagSet("top", &f)
+	interval := fs.Duration("interval", 2*time.Second, "time between refreshes")
+	sortKey := fs.String("sort", "age", "initial `order`: age, newest, id or count")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	key := byte(0)
+	for k, o := range topOrders {
+		if o.name == *sortKey {
+			key = k
+		}
+	}
+	if key == 0 {
+		return fmt.Errorf("unknown order %q", *sortKey)
+	}
+	reverse := false
+
+	keys := make(chan byte)
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		defer term.Restore(fd, state)
+		go func() {
+			b := make([]byte, 1)
+			for {
+				if _, err := os.Stdin.Read(b); err != nil {
+					return
+				}
+				keys <- b[0]
+			}
+		}()
+	}
+
+	// Hide the cursor while drawing and show it again on exit.
+	fmt.Print("\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\r\n")
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		o := topOrders[key]
+		order := o.order
+		if reverse {
+			order = statetrc.Reverse(order)
+		}
+		if err := drawTop(ctx, src, f, o.name, reverse, order); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case k := <-keys:
+			switch {
+			case k == 'q' || k == 3: // q or Ctrl-C
+				return nil
+			case k == 'r':
+				reverse = !reverse
+			default:
+				if _, ok := topOrders[k]; ok {
+					key = k
+				}
+			}
+		}
+	}
+}
+
+// drawTop clears the terminal and draws the entries selected by f in order.
+func drawTop(ctx context.Context, src source, f filter, name string, reverse bool, order statetrc.Order) error {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 120, 40
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[H\x1b[2J")
+	snap, err := src.snapshot(ctx, f)
+	if err != nil {
+		fmt.Fprintf(&buf, "statetrc top: %v", err)
+		os.Stdout.Write(buf.Bytes())
+		return nil
+	}
+	sort.Slice(snap.Entries, order(snap.Entries))
+
+	if reverse {
+		name = "-" + name
+	}
+	fmt.Fprintf(&buf, "statetrc top - %s - %d entries - sort %s (a/n/i/c, r reverse, q quit)\r\n\r\n",
+		snap.At.Format("15:04:05"), len(snap.Entries), name)
+	rows := height - 3
+	if rows < 1 {
+		rows = 1
+	}
+	if len(snap.Entries) > rows-1 {
+		snap.Entries = snap.Entries[:rows-1]
+	}
+
+	var table bytes.Buffer
+	snap.Render(&table, statetrc.TableFormatter{Options: &statetrc.FormatOptions{Round: time.Millisecond}})
+	for _, line := range strings.Split(strings.TrimRight(table.String(), "\n"), "\n") {
+		if len(line) > width {
+			line = line[:width]
+		}
+		buf.WriteString(line)
+		buf.WriteString("\r\n")
+	}
+	_, err = os.Stdout.Write(buf.Bytes())
+	return err
+}
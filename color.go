@@ -0,0 +1,105 @@
+package statetrc
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// ColorMode controls whether a ColorFormatter uses color.
+type ColorMode int
+
+const (
+	// ColorAuto uses color only if the output is a terminal and the NO_COLOR environment
+	// variable is not set.
+	ColorAuto ColorMode = iota
+	// ColorAlways always uses color.
+	ColorAlways
+	// ColorNever never uses color.
+	ColorNever
+)
+
+const (
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiRed    = "\x1b[31m"
+	ansiReset  = "\x1b[0m"
+)
+
+// ColorFormatter writes snapshots in the format of Snapshot.String, coloring each entry with
+// ANSI escape codes by its age: green if it is younger than Warn, yellow if it is younger than
+// Alert and red otherwise. This makes it quick to spot old entries in a terminal.
+type ColorFormatter struct {
+	Mode ColorMode
+	// Warn is the age at which entries turn yellow. Zero means one second.
+	Warn time.Duration
+	// Alert is the age at which entries turn red. Zero means 30 seconds.
+	Alert time.Duration
+	// Options controls rendering. If nil, DefaultFormatOptions are used.
+	Options *FormatOptions
+}
+
+// Format implements Formatter.
+func (f ColorFormatter) Format(w io.Writer, s Snapshot) error {
+	if !f.useColor(w) {
+		return TextFormatter{Options: f.Options}.Format(w, s)
+	}
+
+	o := formatOptions(f.Options)
+	warn, alert := f.Warn, f.Alert
+	if warn <= 0 {
+		warn = time.Second
+	}
+	if alert <= 0 {
+		alert = 30 * time.Second
+	}
+
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(w)
+	for _, e := range s.Entries {
+		age := e.Age(s.At)
+		color := ansiRed
+		switch {
+		case age < warn:
+			color = ansiGreen
+		case age < alert:
+			color = ansiYellow
+		}
+
+		buf.Reset()
+		writeEntry(&buf, e, s.At, o)
+		b := buf.Bytes()
+		i := bytes.IndexByte(b, '\n')
+		bw.WriteString(color)
+		bw.Write(b[:i])
+		bw.WriteString(ansiReset)
+		bw.Write(b[i:])
+	}
+	return bw.Flush()
+}
+
+func (f ColorFormatter) useColor(w io.Writer) bool {
+	switch f.Mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// isTerminal returns true if w is a file that refers to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
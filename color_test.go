@@ -0,0 +1,57 @@
+package statetrc
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColorFormatter(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	s := Snapshot{At: at, Entries: []Entry{
+		{Id: "/new", Time: at, Count: 1},
+		{Id: "/warn", Time: at.Add(-5 * time.Second), Count: 1},
+		{Id: "/old", Time: at.Add(-time.Minute), Count: 1},
+	}}
+	tests := []struct {
+		mode ColorMode
+		want []string
+	}{
+		{ColorAlways, []string{ansiGreen + "/new", ansiYellow + "/warn", ansiRed + "/old"}},
+		{ColorNever, []string{"/new", "/warn", "/old"}},
+		// A bytes.Buffer is not a terminal.
+		{ColorAuto, []string{"/new", "/warn", "/old"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := (ColorFormatter{Mode: tt.mode}).Format(&buf, s); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("mode %d: output %q does not contain %q", tt.mode, out, want)
+			}
+		}
+		if tt.mode != ColorAlways && strings.Contains(out, "\x1b[") {
+			t.Errorf("mode %d: output %q contains escapes", tt.mode, out)
+		}
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	// /dev/null is a character device, but not a terminal.
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Errorf("%s is reported as a terminal", os.DevNull)
+	}
+	if isTerminal(&bytes.Buffer{}) {
+		t.Error("a bytes.Buffer is reported as a terminal")
+	}
+}
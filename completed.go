@@ -0,0 +1,77 @@
+package statetrc
+
+import "time"
+
+// CompletedEvent reports an entry whose state was left.
+type CompletedEvent struct {
+	Id     string
+	Props  interface{}
+	Labels map[string]string
+	// Start and End are the times the state was entered and left, and Duration the time between
+	// them.
+	Start, End time.Time
+	Duration   time.Duration
+}
+
+type completedSub struct {
+	prefix string
+	ch     chan CompletedEvent
+}
+
+// SubscribeCompleted returns a channel on which an event is sent for each completed entry whose
+// id is under the path prefix, and a function that stops the subscription and closes the
+// channel. This allows piping slow completions to custom logging or analytics. Entries are
+// completed as described for OnLeave.
+//
+// Events are sent without blocking the traced code, so if the receiver falls more than
+// WatchBuffer events behind, further events are dropped until it catches up.
+func (t *Tracer) SubscribeCompleted(prefix string) (<-chan CompletedEvent, func()) {
+	s := &completedSub{prefix: t.full(prefix), ch: make(chan CompletedEvent, WatchBuffer)}
+
+	t.mtx.Lock()
+	t.completedSubs = append(t.completedSubs, s)
+	t.mtx.Unlock()
+
+	stopped := false
+	return s.ch, func() {
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+		if stopped {
+			return
+		}
+		stopped = true
+		t.completedSubs = removeHook(t.completedSubs, s)
+		close(s.ch)
+	}
+}
+
+// SubscribeCompleted calls SubscribeCompleted on the default Tracer.
+func SubscribeCompleted(prefix string) (<-chan CompletedEvent, func()) {
+	return std.SubscribeCompleted(prefix)
+}
+
+// sendCompletedLocked sends the completed entry e to the subscribers interested in it.
+// t.mtx must be held.
+func (c *core) sendCompletedLocked(e Entry) {
+	if len(c.completedSubs) == 0 {
+		return
+	}
+	e = c.redactLocked(e)
+	ev := CompletedEvent{
+		Id:       e.Id,
+		Props:    e.Props,
+		Labels:   e.Labels,
+		Start:    e.Time,
+		End:      e.EndTime,
+		Duration: e.EndTime.Sub(e.Time),
+	}
+	for _, s := range c.completedSubs {
+		if !hasPathPrefix(e.Id, s.prefix) {
+			continue
+		}
+		select {
+		case s.ch <- ev:
+		default:
+		}
+	}
+}
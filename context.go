@@ -0,0 +1,56 @@
+package statetrc
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync"
+)
+
+type ctxKey struct{}
+
+// EnterCtx enters the state id like Enter, and returns a context derived from ctx that carries the
+// full id of the entry. ctx is available from the Entry's Context method. See SetPprofLabels for
+// labeling profiles with the id. If tracing is disabled for id, ctx is returned unchanged.
+// The entry is removed when the returned function is called or when ctx is done, whichever happens first.
+// This prevents leaking entries when a goroutine aborts on a cancellation path that skips the normal Leave.
+func (t *Tracer) EnterCtx(ctx context.Context, id string, props interface{}) (context.Context, func()) {
+	if compiledOut {
+		return ctx, func() {}
+	}
+	r := t.enter(Entry{Id: id, Props: props, ctx: ctx})
+	if r == nil {
+		return ctx, func() {}
+	}
+
+	var once sync.Once
+	leave := func() { once.Do(r.Leave) }
+	stop := context.AfterFunc(ctx, leave)
+
+	idCtx := context.WithValue(ctx, ctxKey{}, r.id)
+	if t.pprofLabels.Load() {
+		idCtx = pprof.WithLabels(idCtx, pprof.Labels(PprofLabel, r.id))
+		pprof.SetGoroutineLabels(idCtx)
+		return idCtx, func() {
+			stop()
+			leave()
+			pprof.SetGoroutineLabels(ctx)
+		}
+	}
+
+	return idCtx, func() {
+		stop()
+		leave()
+	}
+}
+
+// EnterCtx calls EnterCtx on the default Tracer.
+func EnterCtx(ctx context.Context, id string, props interface{}) (context.Context, func()) {
+	return std.EnterCtx(ctx, id, props)
+}
+
+// IdFromContext returns the full id, as in Entry.Id, of the innermost entry stored in ctx by
+// EnterCtx.
+func IdFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	return id, ok
+}
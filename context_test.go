@@ -0,0 +1,72 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+)
+
+func TestEnterCtx(t *testing.T) {
+	tr := NewTracer()
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx2, leave := tr.EnterCtx(ctx, "/a", nil)
+	if id, ok := IdFromContext(ctx2); !ok || id != "/a" {
+		t.Errorf("IdFromContext = %q, %v", id, ok)
+	}
+	if e, _ := tr.Get("/a"); e.Context() != ctx {
+		t.Error("the entry does not have the context it was entered with")
+	}
+
+	// The entry is left when the context is done.
+	cancel()
+	for i := 0; len(tr.List(ById)) > 0; i++ {
+		if i == 100 {
+			t.Fatal("the entry was not left when the context was canceled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// Calling the returned function too does not leave it twice.
+	var errs []error
+	tr.SetStrict(func(err error) { errs = append(errs, err) })
+	leave()
+	if len(errs) > 0 {
+		t.Errorf("got errors %v", errs)
+	}
+}
+
+func TestEnterCtxLeave(t *testing.T) {
+	tr := NewTracer()
+	_, leave := tr.EnterCtx(context.Background(), "/a", nil)
+	leave()
+	if l := tr.List(ById); len(l) > 0 {
+		t.Errorf("the returned function did not leave the entry: %v", l)
+	}
+}
+
+func TestEnterCtxNamespace(t *testing.T) {
+	tr := NewTracer()
+	tr.SetPprofLabels(true)
+	ctx, leave := tr.Namespace("/lib").EnterCtx(context.Background(), "/a", nil)
+	defer leave()
+	// The context carries the full id, as in the entry and the pprof label.
+	if id, _ := IdFromContext(ctx); id != "/lib/a" {
+		t.Errorf("IdFromContext = %q, want /lib/a", id)
+	}
+	if l, _ := pprof.Label(ctx, PprofLabel); l != "/lib/a" {
+		t.Errorf("pprof label = %q, want /lib/a", l)
+	}
+}
+
+func TestEnterCtxDisabled(t *testing.T) {
+	tr := NewTracer()
+	tr.DisablePrefix("/off")
+	ctx := context.Background()
+	got, leave := tr.EnterCtx(ctx, "/off/a", nil)
+	if got != ctx {
+		t.Error("EnterCtx returned a new context for a disabled id")
+	}
+	leave()
+}
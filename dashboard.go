@@ -0,0 +1,191 @@
+package statetrc
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DashboardHandler returns an http.Handler that serves a small live dashboard page showing
+// the entries in an auto-refreshing table or tree, with an age filter and a search box. The
+// page has no external assets. It loads the entries from the same URL with format=json, which
+// the handler serves as Handler does, so it can be mounted at any path:
+//
+//	http.Handle("/debug/statetrc/ui", statetrc.DashboardHandler())
+func (t *Tracer) DashboardHandler() http.Handler {
+	return t.authorize(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			t.serveEntries(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		io.WriteString(w, dashboardPage)
+	})
+}
+
+// DashboardHandler calls DashboardHandler on the default Tracer.
+func DashboardHandler() http.Handler {
+	return std.DashboardHandler()
+}
+
+const dashboardPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>statetrc</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+#controls > * { margin-right: 1em; }
+table { border-collapse: collapse; margin-top: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; vertical-align: top; }
+th { cursor: pointer; background: #eee; }
+td.num { text-align: right; }
+pre { margin: 0; white-space: pre-wrap; }
+ul.tree { list-style: none; padding-left: 1.5em; margin: 0; }
+#tree { margin-top: 1em; font-family: monospace; }
+#status { color: #666; }
+</style>
+</head>
+<body>
+<h1>statetrc</h1>
+<div id="controls">
+<label>View <select id="view"><option value="table">Table</option><option value="tree">Tree</option></select></label>
+<label>Older than <input id="minage" size="6" placeholder="e.g. 5s"></label>
+<input id="search" type="search" placeholder="Search" size="30">
+<label>Refresh <select id="refresh"><option value="0">Off</option><option value="1000">1s</option><option value="2000" selected>2s</option><option value="5000">5s</option><option value="10000">10s</option></select></label>
+<span id="status"></span>
+</div>
+<table id="table">
+<thead><tr><th data-key="id">Id</th><th data-key="age_ns">Age</th><th data-key="count">Count</th><th data-key="start">Start</th><th>Props</th></tr></thead>
+<tbody></tbody>
+</table>
+<div id="tree" hidden></div>
+<script>
+(function() {
+  var $ = function(id) { return document.getElementById(id); };
+  var entries = [], sortKey = "id", sortDir = 1, timer = null;
+
+  function text(e) {
+    var s = e.id;
+    if (e.labels) for (var k in e.labels) s += " " + k + "=" + e.labels[k];
+    if (e.props !== undefined) s += " " + JSON.stringify(e.props);
+    return s.toLowerCase();
+  }
+
+  function visible() {
+    var q = $("search").value.toLowerCase();
+    return entries.filter(function(e) { return text(e).indexOf(q) >= 0; });
+  }
+
+  function props(e) {
+    var s = "";
+    if (e.labels) for (var k in e.labels) s += k + "=" + e.labels[k] + " ";
+    if (e.props !== undefined) s += typeof e.props === "string" ? e.props : JSON.stringify(e.props);
+    return s;
+  }
+
+  function cell(row, v, cls) {
+    var td = row.insertCell();
+    if (cls) td.className = cls;
+    td.textContent = v;
+    return td;
+  }
+
+  function renderTable(list) {
+    list.sort(function(a, b) {
+      var x = a[sortKey], y = b[sortKey];
+      return (x < y ? -1 : x > y ? 1 : 0) * sortDir;
+    });
+    var body = $("table").tBodies[0];
+    body.innerHTML = "";
+    list.forEach(function(e) {
+      var row = body.insertRow();
+      cell(row, e.id);
+      cell(row, e.age, "num");
+      cell(row, e.count, "num");
+      cell(row, e.start);
+      var pre = document.createElement("pre");
+      pre.textContent = props(e);
+      row.insertCell().appendChild(pre);
+    });
+  }
+
+  function renderTree(list) {
+    var root = {children: {}, count: 0, oldest: 0};
+    list.forEach(function(e) {
+      var n = root;
+      n.count++;
+      n.oldest = Math.max(n.oldest, e.age_ns);
+      e.id.split("/").filter(function(p) { return p !== ""; }).forEach(function(p) {
+        n = n.children[p] = n.children[p] || {children: {}, count: 0, oldest: 0};
+        n.count++;
+        n.oldest = Math.max(n.oldest, e.age_ns);
+      });
+    });
+    function build(n) {
+      var ul = document.createElement("ul");
+      ul.className = "tree";
+      Object.keys(n.children).sort().forEach(function(name) {
+        var c = n.children[name];
+        var li = document.createElement("li");
+        li.textContent = name + " (" + c.count + ", oldest " + (c.oldest / 1e9).toFixed(3) + "s)";
+        li.appendChild(build(c));
+        ul.appendChild(li);
+      });
+      return ul;
+    }
+    $("tree").innerHTML = "";
+    $("tree").appendChild(build(root));
+  }
+
+  function render() {
+    var tree = $("view").value === "tree";
+    $("table").hidden = tree;
+    $("tree").hidden = !tree;
+    var list = visible();
+    if (tree) renderTree(list); else renderTable(list);
+  }
+
+  function load() {
+    var url = location.pathname + "?format=json";
+    var age = $("minage").value.trim();
+    if (age !== "") url += "&min-age=" + encodeURIComponent(age);
+    fetch(url).then(function(r) {
+      if (!r.ok) return r.text().then(function(t) { throw new Error(t); });
+      return r.json();
+    }).then(function(s) {
+      entries = s.entries || [];
+      $("status").textContent = entries.length + " entries at " + new Date(s.at).toLocaleTimeString();
+      render();
+    }).catch(function(err) {
+      $("status").textContent = "Error: " + err.message;
+    });
+  }
+
+  function schedule() {
+    clearInterval(timer);
+    var ms = Number($("refresh").value);
+    if (ms > 0) timer = setInterval(load, ms);
+  }
+
+  Array.prototype.forEach.call($("table").tHead.rows[0].cells, function(th) {
+    var key = th.getAttribute("data-key");
+    if (!key) return;
+    th.addEventListener("click", function() {
+      sortDir = sortKey === key ? -sortDir : 1;
+      sortKey = key;
+      render();
+    });
+  });
+  $("view").addEventListener("change", render);
+  $("search").addEventListener("input", render);
+  $("minage").addEventListener("change", load);
+  $("refresh").addEventListener("change", schedule);
+  load();
+  schedule();
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,29 @@
+package statetrc
+
+// DiagnosticCounters holds counters of the calls made to a Tracer, for monitoring the tracing itself.
+// The counters are shared by a Tracer and its namespaces.
+type DiagnosticCounters struct {
+	// Enters is the number of entries and instances entered.
+	Enters uint64
+	// Leaves is the number of entries and instances left, including those left by LeavePrefix.
+	// Entries that expired or were removed by Clear or Disable are not counted.
+	Leaves uint64
+	// Overwrites is the number of entries that replaced an existing entry in Overwrite mode,
+	// discarding its start time and properties.
+	Overwrites uint64
+	// OrphanLeaves is the number of calls to leave an entry or instance that did not exist.
+	// They usually mean that an Enter was missed or an id was misspelled.
+	OrphanLeaves uint64
+}
+
+// Diagnostics returns the counters of the Tracer.
+func (t *Tracer) Diagnostics() DiagnosticCounters {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.diag
+}
+
+// Diagnostics calls Diagnostics on the default Tracer.
+func Diagnostics() DiagnosticCounters {
+	return std.Diagnostics()
+}
@@ -0,0 +1,40 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import "testing"
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(tr *Tracer)
+		want DiagnosticCounters
+	}{
+		{"enter and leave", func(tr *Tracer) { tr.Enter("/a", nil); tr.Leave("/a") }, DiagnosticCounters{Enters: 1, Leaves: 1}},
+		{"overwrite", func(tr *Tracer) { tr.Enter("/a", nil); tr.Enter("/a", nil) }, DiagnosticCounters{Enters: 2, Overwrites: 1}},
+		{"orphan", func(tr *Tracer) { tr.Leave("/a") }, DiagnosticCounters{OrphanLeaves: 1}},
+		{"leave prefix", func(tr *Tracer) {
+			tr.SetMode(RefCount)
+			tr.Enter("/a/1", nil)
+			tr.Enter("/a/1", nil)
+			tr.Enter("/a/2", nil)
+			tr.LeavePrefix("/a")
+		}, DiagnosticCounters{Enters: 3, Leaves: 3}},
+		{"clear", func(tr *Tracer) { tr.Enter("/a", nil); tr.Clear() }, DiagnosticCounters{Enters: 1}},
+		{"disabled", func(tr *Tracer) {
+			tr.DisablePrefix("/off")
+			tr.Enter("/off/a", nil)
+			tr.Leave("/off/a")
+			tr.LeavePrefix("/off")
+		}, DiagnosticCounters{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTracer()
+			tt.fn(tr)
+			if got := tr.Diagnostics(); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
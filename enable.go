@@ -0,0 +1,117 @@
+package statetrc
+
+// Disable turns off tracing. While disabled Enter and Leave do nothing and cost a single atomic
+// load, so instrumentation can be left in hot paths. The existing entries are removed, since
+// the Leave calls that would remove them are ignored. For a namespace, Disable is the same as
+// DisablePrefix("").
+func (t *Tracer) Disable() {
+	if t.prefix != "" {
+		t.DisablePrefix("")
+		return
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.disabled.Store(true)
+	t.resetLocked()
+}
+
+// Disable calls Disable on the default Tracer.
+func Disable() {
+	std.Disable()
+}
+
+// Enable turns tracing back on after Disable. Prefixes disabled with DisablePrefix stay disabled.
+// For a namespace, Enable is the same as EnablePrefix("").
+func (t *Tracer) Enable() {
+	if t.prefix != "" {
+		t.EnablePrefix("")
+		return
+	}
+	t.disabled.Store(false)
+}
+
+// Enable calls Enable on the default Tracer.
+func Enable() {
+	std.Enable()
+}
+
+// DisablePrefix turns off tracing for ids under the path prefix, and removes the existing
+// entries under it. Enter, Leave, Update and Touch then do nothing for those ids, without
+// locking the Tracer. See CountPrefix for how prefixes are matched.
+func (t *Tracer) DisablePrefix(prefix string) {
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	var l []string
+	if p := t.offPrefixes.Load(); p != nil {
+		l = append(l, *p...)
+	}
+	l = append(l, prefix)
+	t.offPrefixes.Store(&l)
+
+	for id := range t.entries {
+		if hasPathPrefix(id, prefix) {
+			t.deleteLocked(id, false)
+		}
+	}
+}
+
+// DisablePrefix calls DisablePrefix on the default Tracer.
+func DisablePrefix(prefix string) {
+	std.DisablePrefix(prefix)
+}
+
+// EnablePrefix turns tracing back on for a prefix previously passed to DisablePrefix.
+func (t *Tracer) EnablePrefix(prefix string) {
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	p := t.offPrefixes.Load()
+	if p == nil {
+		return
+	}
+	var l []string
+	for _, s := range *p {
+		if s != prefix {
+			l = append(l, s)
+		}
+	}
+	if len(l) == 0 {
+		t.offPrefixes.Store(nil)
+		return
+	}
+	t.offPrefixes.Store(&l)
+}
+
+// EnablePrefix calls EnablePrefix on the default Tracer.
+func EnablePrefix(prefix string) {
+	std.EnablePrefix(prefix)
+}
+
+// Enabled returns true if tracing is enabled for id.
+func (t *Tracer) Enabled(id string) bool {
+	return !t.off(t.full(id))
+}
+
+// Enabled calls Enabled on the default Tracer.
+func Enabled(id string) bool {
+	return std.Enabled(id)
+}
+
+// off returns true if tracing is disabled for the full id.
+func (c *core) off(id string) bool {
+	if c.disabled.Load() {
+		return true
+	}
+	if p := c.offPrefixes.Load(); p != nil {
+		for _, prefix := range *p {
+			if hasPathPrefix(id, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
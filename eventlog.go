@@ -0,0 +1,40 @@
+package statetrc
+
+import "time"
+
+// SetEventLogSize enables recording of up to n events in a log, so that the recent transitions
+// of the states can be reconstructed when debugging races and ordering problems. Every change
+// that would be sent to a watcher by Watch is recorded, that is each enter, leave and update.
+// The oldest events are discarded when more than n have been recorded. Passing zero disables
+// recording. Changing the size discards the recorded events.
+func (t *Tracer) SetEventLogSize(n int) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.events.resize(n)
+}
+
+// SetEventLogSize calls SetEventLogSize on the default Tracer.
+func SetEventLogSize(n int) {
+	std.SetEventLogSize(n)
+}
+
+// Events returns the recorded events that happened after since, oldest first. Pass the zero
+// time for all recorded events. For a namespace only events of entries under its prefix are
+// returned.
+func (t *Tracer) Events(since time.Time) []Event {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	l := t.events.list()
+	res := l[:0]
+	for _, ev := range l {
+		if ev.Time.After(since) && t.inScope(ev.Entry.Id) {
+			res = append(res, ev)
+		}
+	}
+	return res
+}
+
+// Events calls Events on the default Tracer.
+func Events(since time.Time) []Event {
+	return std.Events(since)
+}
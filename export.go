@@ -0,0 +1,126 @@
+package statetrc
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteDOT writes the hierarchy of entry ids in the Snapshot to w as a Graphviz DOT graph. Each
+// node is filled with a color ranging from green for the newest to red for the oldest subtree,
+// so that stuck subtrees stand out. Parent links recorded with EnterChild are drawn as dashed edges.
+func (s Snapshot) WriteDOT(w io.Writer) error {
+	root := s.Tree()
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph statetrc {")
+	fmt.Fprintln(bw, "  node [shape=box, style=filled];")
+
+	var nodes func(n *Node)
+	nodes = func(n *Node) {
+		for _, c := range n.Children {
+			fmt.Fprintf(bw, "  %s [label=%s, fillcolor=%q];\n", strconv.Quote(c.Path),
+				strconv.Quote(fmt.Sprintf("%s\n%d, %s", c.Name, c.Count, DefaultFormatOptions.duration(c.MaxAge))),
+				ageColor(c.MaxAge, root.MaxAge))
+			if n != root {
+				fmt.Fprintf(bw, "  %s -> %s;\n", strconv.Quote(n.Path), strconv.Quote(c.Path))
+			}
+			nodes(c)
+		}
+	}
+	nodes(root)
+
+	for _, e := range s.Entries {
+		if e.Parent != "" {
+			fmt.Fprintf(bw, "  %s -> %s [style=dashed];\n", strconv.Quote(e.Parent), strconv.Quote(e.Id))
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// WriteDOT writes the currently existing entries to w as a DOT graph. See Snapshot.WriteDOT.
+func (t *Tracer) WriteDOT(w io.Writer) error {
+	return t.Capture(ById).WriteDOT(w)
+}
+
+// WriteDOT calls WriteDOT on the default Tracer.
+func WriteDOT(w io.Writer) error {
+	return std.WriteDOT(w)
+}
+
+// ageColor returns a Graphviz HSV color between green for an age of zero and red for max.
+func ageColor(age, max time.Duration) string {
+	f := 0.0
+	if max > 0 {
+		f = float64(age) / float64(max)
+	}
+	return fmt.Sprintf("%.3f 0.5 1.0", (1-f)/3)
+}
+
+// WriteCSV writes the entries of the Snapshot to w as CSV with a header row and the columns
+// id, start (in RFC 3339 format), age (in seconds, relative to the capture time) and props,
+// for offline analysis with spreadsheets or command-line tools.
+func (s Snapshot) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "start", "age", "props"})
+	for _, e := range s.Entries {
+		cw.Write([]string{
+			e.Id,
+			e.Time.Format(time.RFC3339Nano),
+			strconv.FormatFloat(e.Age(s.At).Seconds(), 'f', -1, 64),
+			propsString(e, &DefaultFormatOptions),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteCSV writes the currently existing entries to w as CSV. See Snapshot.WriteCSV.
+func (t *Tracer) WriteCSV(w io.Writer) error {
+	return t.Capture(ById).WriteCSV(w)
+}
+
+// WriteCSV calls WriteCSV on the default Tracer.
+func WriteCSV(w io.Writer) error {
+	return std.WriteCSV(w)
+}
+
+// WriteFolded writes the entries of the Snapshot to w in the folded stacks format used by
+// flamegraph tools such as Brendan Gregg's flamegraph.pl. The elements of each id are used as
+// stack frames and its age in milliseconds as the weight, so the graph shows where time is
+// currently being spent:
+//
+//	conn;17;read 1520
+func (s Snapshot) WriteFolded(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range s.Entries {
+		var frames []string
+		for _, f := range strings.Split(e.Id, "/") {
+			if f != "" {
+				frames = append(frames, strings.ReplaceAll(f, ";", "_"))
+			}
+		}
+		if len(frames) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "%s %d\n", strings.Join(frames, ";"), e.Age(s.At).Milliseconds())
+	}
+	return bw.Flush()
+}
+
+// WriteFolded writes the currently existing entries to w in folded stacks format.
+// See Snapshot.WriteFolded.
+func (t *Tracer) WriteFolded(w io.Writer) error {
+	return t.Capture(ById).WriteFolded(w)
+}
+
+// WriteFolded calls WriteFolded on the default Tracer.
+func WriteFolded(w io.Writer) error {
+	return std.WriteFolded(w)
+}
@@ -0,0 +1,32 @@
+package statetrc
+
+import "expvar"
+
+// Expvar returns an expvar.Var whose value is a JSON object with the number of entries as
+// "count", the number of entries under each top-level prefix as "prefixes", and the n oldest
+// entries as "oldest", or all of them if n is zero or less, encoded as by Entry.MarshalJSON.
+func (t *Tracer) Expvar(n int) expvar.Var {
+	return expvar.Func(func() interface{} {
+		s := t.snapshot(nil, ByAge)
+		prefixes := map[string]int{}
+		for _, p := range s.Aggregate(1) {
+			prefixes[p.Prefix] = p.Count
+		}
+		oldest := s.Entries
+		if n > 0 && n < len(oldest) {
+			oldest = oldest[:n]
+		}
+		return map[string]interface{}{
+			"count":    len(s.Entries),
+			"prefixes": prefixes,
+			"oldest":   toJSONEntries(oldest, s.At),
+		}
+	})
+}
+
+// PublishExpvar publishes the value of Expvar(10) for the default Tracer under the name
+// "statetrc", so that it is served by the /debug/vars handler of package expvar. Like
+// expvar.Publish it panics if it is called more than once.
+func PublishExpvar() {
+	expvar.Publish("statetrc", std.Expvar(10))
+}
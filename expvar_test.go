@@ -0,0 +1,36 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExpvar(t *testing.T) {
+	tr := NewTracer()
+	for _, id := range []string{"/conn/1", "/conn/2", "/job"} {
+		tr.Enter(id, nil)
+	}
+	tests := []struct {
+		n, oldest int
+	}{
+		{0, 3},
+		{-1, 3},
+		{2, 2},
+		{10, 3},
+	}
+	for _, tt := range tests {
+		var v struct {
+			Count    int
+			Prefixes map[string]int
+			Oldest   []interface{}
+		}
+		if err := json.Unmarshal([]byte(tr.Expvar(tt.n).String()), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.Count != 3 || v.Prefixes["/conn"] != 2 || len(v.Oldest) != tt.oldest {
+			t.Errorf("Expvar(%d) = %+v, want %d oldest entries", tt.n, v, tt.oldest)
+		}
+	}
+}
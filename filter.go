@@ -0,0 +1,161 @@
+package statetrc
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ListPrefix returns the currently existing entries whose ids are under the path prefix,
+// ordered in the specified Order. See CountPrefix for how prefixes are matched.
+func (t *Tracer) ListPrefix(prefix string, order Order) EntrySlice {
+	prefix = t.full(prefix)
+	return t.list(func(e *Entry) bool {
+		return hasPathPrefix(e.Id, prefix)
+	}, order)
+}
+
+// ListPrefix calls ListPrefix on the default Tracer.
+func ListPrefix(prefix string, order Order) EntrySlice {
+	return std.ListPrefix(prefix, order)
+}
+
+// ListGlob returns the currently existing entries whose ids match the glob pattern, ordered in
+// the specified Order. The pattern is matched against ids element by element, where elements are
+// separated by '/'. An element of "**" matches any number of id elements, including none, and
+// other elements are matched as by path.Match, so "/conn/*/read" matches "/conn/17/read" and
+// "/job/**" matches "/job" and everything under it. An error is returned if the pattern is malformed.
+func (t *Tracer) ListGlob(pattern string, order Order) (EntrySlice, error) {
+	g, err := compileGlob(t.full(pattern))
+	if err != nil {
+		return nil, err
+	}
+	return t.list(func(e *Entry) bool {
+		return g.match(e.Id)
+	}, order), nil
+}
+
+// ListGlob calls ListGlob on the default Tracer.
+func ListGlob(pattern string, order Order) (EntrySlice, error) {
+	return std.ListGlob(pattern, order)
+}
+
+// glob is a compiled glob pattern for ids. See ListGlob.
+type glob []string
+
+func compileGlob(pattern string) (glob, error) {
+	g := glob(strings.Split(pattern, "/"))
+	for _, elem := range g {
+		if elem == "**" {
+			continue
+		}
+		if _, err := path.Match(elem, ""); err != nil {
+			return nil, fmt.Errorf("statetrc: bad glob pattern %q: %w", pattern, err)
+		}
+	}
+	return g, nil
+}
+
+func (g glob) match(id string) bool {
+	return matchElems(g, strings.Split(id, "/"))
+}
+
+func matchElems(pattern, id []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(id); i++ {
+				if matchElems(pattern[1:], id[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(id) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], id[0]); !ok {
+			return false
+		}
+		pattern, id = pattern[1:], id[1:]
+	}
+	return len(id) == 0
+}
+
+// ListMatch returns the currently existing entries whose ids match the regular expression,
+// ordered in the specified Order. For a namespace the expression is matched against full ids.
+func (t *Tracer) ListMatch(re *regexp.Regexp, order Order) EntrySlice {
+	return t.list(func(e *Entry) bool {
+		return re.MatchString(e.Id)
+	}, order)
+}
+
+// ListMatch calls ListMatch on the default Tracer.
+func ListMatch(re *regexp.Regexp, order Order) EntrySlice {
+	return std.ListMatch(re, order)
+}
+
+// ListOlderThan returns the currently existing entries that have been active for longer
+// than d, ordered in the specified Order.
+func (t *Tracer) ListOlderThan(d time.Duration, order Order) EntrySlice {
+	var now time.Time
+	return t.list(func(e *Entry) bool {
+		if now.IsZero() {
+			now = t.nowLocked()
+		}
+		return e.Age(now) > d
+	}, order)
+}
+
+// ListOlderThan calls ListOlderThan on the default Tracer.
+func ListOlderThan(d time.Duration, order Order) EntrySlice {
+	return std.ListOlderThan(d, order)
+}
+
+// ListWhere returns the currently existing entries for which keep returns true, ordered in the
+// specified Order. This allows filtering on the contents of Props. keep is called after the
+// entries have been copied, so it may call methods of the Tracer.
+func (t *Tracer) ListWhere(keep func(e Entry) bool, order Order) EntrySlice {
+	l := t.List(order)
+	res := l[:0]
+	for _, e := range l {
+		if keep(e) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// ListWhere calls ListWhere on the default Tracer.
+func ListWhere(keep func(e Entry) bool, order Order) EntrySlice {
+	return std.ListWhere(keep, order)
+}
+
+// ListPage returns at most limit of the currently existing entries, starting at offset in the
+// specified Order, along with the total number of entries. A limit of zero or less returns all
+// entries after offset. This allows debug endpoints and UIs to page through very large listings.
+func (t *Tracer) ListPage(order Order, offset, limit int) (EntrySlice, int) {
+	l := t.List(order)
+	return page(l, offset, limit), len(l)
+}
+
+// ListPage calls ListPage on the default Tracer.
+func ListPage(order Order, offset, limit int) (EntrySlice, int) {
+	return std.ListPage(order, offset, limit)
+}
+
+// page returns the part of l selected by offset and limit, as described for ListPage.
+func page[S ~[]E, E any](l S, offset, limit int) S {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(l) {
+		offset = len(l)
+	}
+	l = l[offset:]
+	if limit > 0 && limit < len(l) {
+		l = l[:limit]
+	}
+	return l
+}
@@ -0,0 +1,226 @@
+package statetrc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// Formatter writes a Snapshot to w in some format.
+type Formatter interface {
+	Format(w io.Writer, s Snapshot) error
+}
+
+// Render writes the Snapshot to w using the Formatter f. (It is not named WriteTo
+// because that name is reserved for implementations of io.WriterTo.)
+func (s Snapshot) Render(w io.Writer, f Formatter) error {
+	return f.Format(w, s)
+}
+
+// TextFormatter writes snapshots in the format of Snapshot.String. If Group is greater than
+// zero, sibling entries are collapsed as by Snapshot.StringGrouped with Group and Expand.
+type TextFormatter struct {
+	Group  int
+	Expand []string
+	// Options controls rendering. If nil, DefaultFormatOptions are used.
+	Options *FormatOptions
+}
+
+// Format implements Formatter.
+func (f TextFormatter) Format(w io.Writer, s Snapshot) error {
+	o := formatOptions(f.Options)
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(w)
+	if f.Group > 0 {
+		writeGrouped(&buf, s, f.Group, f.Expand, o)
+		_, err := buf.WriteTo(bw)
+		if err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+	for _, e := range s.Entries {
+		buf.Reset()
+		writeEntry(&buf, e, s.At, o)
+		if _, err := buf.WriteTo(bw); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// JSONFormatter writes snapshots as JSON, as encoded by Snapshot.MarshalJSON. If Indent is
+// not empty the output is indented with it.
+type JSONFormatter struct {
+	Indent string
+	// Options controls rendering. Only MaxProps applies: props whose encoding is longer are
+	// replaced by a truncated string. If nil, DefaultFormatOptions are used.
+	Options *FormatOptions
+}
+
+// Format implements Formatter.
+func (f JSONFormatter) Format(w io.Writer, s Snapshot) error {
+	o := formatOptions(f.Options)
+	j := jsonSnapshot{At: s.At, Entries: toJSONEntries(s.Entries, s.At)}
+	if o.MaxProps > 0 {
+		for i := range j.Entries {
+			if p := j.Entries[i].Props; len(p) > o.MaxProps {
+				j.Entries[i].Props, _ = json.Marshal(o.props(string(p)))
+			}
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", f.Indent)
+	return enc.Encode(j)
+}
+
+// TableFormatter writes snapshots as a table with a row for each entry and aligned columns
+// for the id, age, count and properties.
+type TableFormatter struct {
+	// Keys are the names of labels or properties to show in their own columns, looked up as
+	// by ByPropsField. If Keys is empty a single PROPS column shows the labels and Props.
+	Keys []string
+	// Order sorts the rows if it is not nil.
+	Order Order
+	// MaxWidth is the maximum width of a cell. Longer values are truncated. Zero means 60.
+	MaxWidth int
+	// Options controls rendering. If ShowStart is set a START column is included.
+	// If nil, DefaultFormatOptions are used.
+	Options *FormatOptions
+}
+
+// Format implements Formatter.
+func (f TableFormatter) Format(w io.Writer, s Snapshot) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, row := range tableRows(s, f.Keys, f.Order, f.MaxWidth, formatOptions(f.Options)) {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// tableRows returns the header and the rows of the table written by TableFormatter.
+func tableRows(s Snapshot, keys []string, order Order, width int, o *FormatOptions) [][]string {
+	if width <= 0 {
+		width = 60
+	}
+
+	l := s.Entries
+	if order != nil {
+		l = append(EntrySlice(nil), l...)
+		sort.Slice(l, order(l))
+	}
+
+	header := []string{"ID", "AGE", "COUNT"}
+	if o.ShowStart {
+		header = append(header, "START")
+	}
+	if len(keys) == 0 {
+		header = append(header, "PROPS")
+	}
+	for _, k := range keys {
+		header = append(header, strings.ToUpper(k))
+	}
+	rows := [][]string{header}
+
+	for _, e := range l {
+		row := []string{cell(e.Id, width), o.duration(e.Age(s.At)), strconv.Itoa(e.Count)}
+		if o.ShowStart {
+			row = append(row, o.start(e.Time))
+		}
+		if len(keys) == 0 {
+			row = append(row, cell(propsString(e, o), width))
+		}
+		for _, k := range keys {
+			v, ok := propsField(e, k)
+			if !ok {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, cell(fmt.Sprint(v), width))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// propsString returns the labels and Props of e formatted on one line, for formats that
+// show them in a single column, limited to o.MaxProps characters.
+func propsString(e Entry, o *FormatOptions) string {
+	props := ""
+	if e.Props != nil {
+		props = fmt.Sprintf("%v", e.Props)
+	}
+	if len(e.Labels) > 0 {
+		props = strings.TrimSpace(formatLabels(e.Labels) + " " + props)
+	}
+	return o.props(props)
+}
+
+// cell prepares s for use as a table cell by putting it on one line and truncating it to width.
+func cell(s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return truncate(s, width)
+}
+
+// truncate shortens s to at most n runes, ending it with "..." if it was shortened.
+func truncate(s string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string([]rune(s)[:n])
+	}
+	return string([]rune(s)[:n-3]) + "..."
+}
+
+// Dump captures a Snapshot of the currently existing entries ordered by id and writes it
+// to w using the Formatter f.
+func (t *Tracer) Dump(w io.Writer, f Formatter) error {
+	return t.Capture(ById).Render(w, f)
+}
+
+// Dump calls Dump on the default Tracer.
+func Dump(w io.Writer, f Formatter) error {
+	return std.Dump(w, f)
+}
+
+// TreeFormatter writes snapshots as a tree of the elements of entry ids drawn with branch lines,
+// like the tree command, showing the number of entries and the oldest age within each subtree:
+//
+//	conn (3, oldest 1m30s)
+//	├── 1 (1, oldest 1m30s)
+//	└── 2 (2, oldest 50s)
+//	    └── read (1, oldest 50s)
+type TreeFormatter struct {
+	// Options controls rendering. If nil, DefaultFormatOptions are used.
+	Options *FormatOptions
+}
+
+// Format implements Formatter.
+func (f TreeFormatter) Format(w io.Writer, s Snapshot) error {
+	o := formatOptions(f.Options)
+	bw := bufio.NewWriter(w)
+	for _, c := range s.Tree().Children {
+		fmt.Fprintf(bw, "%s (%d, oldest %s)\n", c.Name, c.Count, o.duration(c.MaxAge))
+		writeBranches(bw, c, "", o)
+	}
+	return bw.Flush()
+}
+
+func writeBranches(w *bufio.Writer, n *Node, indent string, o *FormatOptions) {
+	for i, c := range n.Children {
+		branch, next := "├── ", "│   "
+		if i == len(n.Children)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintf(w, "%s%s%s (%d, oldest %s)\n", indent, branch, c.Name, c.Count, o.duration(c.MaxAge))
+		writeBranches(w, c, indent+next, o)
+	}
+}
@@ -0,0 +1,80 @@
+package statetrc
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StringGrouped formats the entries of the Snapshot like String, except that when min or more
+// entries share the same parent path they are collapsed into a single line such as
+//
+//	/conn/* (342 entries, oldest 4m12s)
+//
+// The line is written in place of the first of the collapsed entries. Siblings under the parent
+// paths listed in expand are not collapsed. This keeps dumps readable when there are very many
+// items of a set.
+func (s Snapshot) StringGrouped(min int, expand ...string) string {
+	var buf bytes.Buffer
+	writeGrouped(&buf, s, min, expand, &DefaultFormatOptions)
+	return buf.String()
+}
+
+type siblingGroup struct {
+	count   int
+	oldest  time.Duration
+	written bool
+}
+
+// groupSiblings returns the groups of entries in s that share a parent path, for the parents that
+// have at least min entries and are not in expand.
+func groupSiblings(s Snapshot, min int, expand []string) map[string]*siblingGroup {
+	groups := map[string]*siblingGroup{}
+	for _, e := range s.Entries {
+		p := parentPath(e.Id)
+		g, ok := groups[p]
+		if !ok {
+			g = &siblingGroup{}
+			groups[p] = g
+		}
+		g.count++
+		if age := e.Age(s.At); age > g.oldest {
+			g.oldest = age
+		}
+	}
+
+	for p, g := range groups {
+		if g.count < min || min <= 0 {
+			delete(groups, p)
+		}
+	}
+	for _, p := range expand {
+		delete(groups, p)
+	}
+	return groups
+}
+
+func writeGrouped(buf *bytes.Buffer, s Snapshot, min int, expand []string, o *FormatOptions) {
+	groups := groupSiblings(s, min, expand)
+	for _, e := range s.Entries {
+		p := parentPath(e.Id)
+		g, ok := groups[p]
+		if !ok {
+			writeEntry(buf, e, s.At, o)
+			continue
+		}
+		if !g.written {
+			fmt.Fprintf(buf, "%s/* (%d entries, oldest %s)\n", p, g.count, o.duration(g.oldest))
+			g.written = true
+		}
+	}
+}
+
+// parentPath returns id without its last element.
+func parentPath(id string) string {
+	if i := strings.LastIndexByte(id, '/'); i >= 0 {
+		return id[:i]
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package grpctrc
+
+import (
+	"context"
+
+	"github.com/jeffwilliams/statetrc"
+	"google.golang.org/grpc"
+)
+
+// Client calls the service on a connection.
+type Client struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewClient returns a Client that calls the service on cc.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{cc: cc}
+}
+
+// ListEntries returns the entries selected by query, as for statetrc.Query.
+func (c *Client) ListEntries(ctx context.Context, query string, opts ...grpc.CallOption) (statetrc.Snapshot, error) {
+	var s statetrc.Snapshot
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/ListEntries", &ListRequest{Query: query}, &s, callOptions(opts)...)
+	return s, err
+}
+
+// Aggregate returns statistics for the entries grouped by the first depth elements of their ids.
+func (c *Client) Aggregate(ctx context.Context, depth int, opts ...grpc.CallOption) ([]statetrc.PrefixStat, error) {
+	var resp AggregateResponse
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/Aggregate", &AggregateRequest{Depth: depth}, &resp, callOptions(opts)...)
+	return resp.Stats, err
+}
+
+// Clear removes the entries under prefix and returns the number removed.
+func (c *Client) Clear(ctx context.Context, prefix string, opts ...grpc.CallOption) (int, error) {
+	var resp ClearResponse
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/Clear", &ClearRequest{Prefix: prefix}, &resp, callOptions(opts)...)
+	return resp.Removed, err
+}
+
+// Watch calls fn with each change to the entries under prefix until ctx is done, fn returns
+// false or the call fails. It returns nil if fn returned false, and otherwise the error that
+// ended the call.
+func (c *Client) Watch(ctx context.Context, prefix string, fn func(ev statetrc.Event) bool, opts ...grpc.CallOption) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0], "/"+ServiceName+"/Watch", callOptions(opts)...)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(&WatchRequest{Prefix: prefix}); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		var ev statetrc.Event
+		if err := stream.RecvMsg(&ev); err != nil {
+			return err
+		}
+		if !fn(ev) {
+			return nil
+		}
+	}
+}
+
+// callOptions returns opts with the content-subtype of the service's codec added.
+func callOptions(opts []grpc.CallOption) []grpc.CallOption {
+	return append([]grpc.CallOption{grpc.CallContentSubtype(ContentSubtype)}, opts...)
+}
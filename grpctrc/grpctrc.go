@@ -0,0 +1,178 @@
+// Package grpctrc provides a gRPC service for querying a statetrc Tracer, so that tools can
+// list, aggregate, watch and clear the entries of a remote process. The service is defined in
+// grpctrc.proto.
+//
+// The messages are encoded by a codec registered under the content-subtype "statetrc" rather
+// than by generated code, so clients must either use Client or pass
+// grpc.CallContentSubtype(grpctrc.ContentSubtype) on their calls. To serve a Tracer:
+//
+//	s := grpc.NewServer()
+//	grpctrc.Register(s, statetrc.Default())
+package grpctrc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jeffwilliams/statetrc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+// ContentSubtype is the gRPC content-subtype of the codec used by the service.
+const ContentSubtype = "statetrc"
+
+// ServiceName is the full name of the service in grpctrc.proto.
+const ServiceName = "statetrc.grpctrc.Statetrc"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// message is implemented by the messages of the service.
+type message interface {
+	MarshalProto() ([]byte, error)
+	UnmarshalProto(b []byte) error
+}
+
+// codec encodes messages with their MarshalProto and UnmarshalProto methods.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(message)
+	if !ok {
+		return nil, fmt.Errorf("grpctrc: cannot marshal %T", v)
+	}
+	return m.MarshalProto()
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(message)
+	if !ok {
+		return fmt.Errorf("grpctrc: cannot unmarshal into %T", v)
+	}
+	return m.UnmarshalProto(data)
+}
+
+func (codec) Name() string {
+	return ContentSubtype
+}
+
+// Server implements the service for a Tracer.
+type Server struct {
+	t *statetrc.Tracer
+}
+
+// NewServer returns a Server for t. If t is nil the default Tracer is used.
+func NewServer(t *statetrc.Tracer) *Server {
+	if t == nil {
+		t = statetrc.Default()
+	}
+	return &Server{t: t}
+}
+
+// Register registers a Server for t with s.
+func Register(s grpc.ServiceRegistrar, t *statetrc.Tracer) {
+	s.RegisterService(&serviceDesc, NewServer(t))
+}
+
+// ListEntries returns the entries selected by the query in req.
+func (s *Server) ListEntries(ctx context.Context, req *ListRequest) (*statetrc.Snapshot, error) {
+	snap, err := s.t.QuerySnapshot(req.Query)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &snap, nil
+}
+
+// Watch sends changes to the entries under the prefix in req until the client cancels the call.
+func (s *Server) Watch(req *WatchRequest, stream grpc.ServerStream) error {
+	ch, stop := s.t.Watch(req.Prefix)
+	defer stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := stream.SendMsg(&ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Aggregate returns statistics for the entries grouped by the first req.Depth elements of their ids.
+func (s *Server) Aggregate(ctx context.Context, req *AggregateRequest) (*AggregateResponse, error) {
+	if req.Depth <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "grpctrc: depth must be positive")
+	}
+	return &AggregateResponse{Stats: s.t.Aggregate(req.Depth)}, nil
+}
+
+// Clear removes the entries under the prefix in req as ClearPrefix does, so they are not
+// recorded as completed.
+func (s *Server) Clear(ctx context.Context, req *ClearRequest) (*ClearResponse, error) {
+	return &ClearResponse{Removed: s.t.ClearPrefix(req.Prefix)}, nil
+}
+
+// service is the interface checked by RegisterService.
+type service interface {
+	ListEntries(ctx context.Context, req *ListRequest) (*statetrc.Snapshot, error)
+	Watch(req *WatchRequest, stream grpc.ServerStream) error
+	Aggregate(ctx context.Context, req *AggregateRequest) (*AggregateResponse, error)
+	Clear(ctx context.Context, req *ClearRequest) (*ClearResponse, error)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*service)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "ListEntries", Handler: unaryHandler("ListEntries", func(s service, ctx context.Context, req *ListRequest) (interface{}, error) {
+			return s.ListEntries(ctx, req)
+		})},
+		{MethodName: "Aggregate", Handler: unaryHandler("Aggregate", func(s service, ctx context.Context, req *AggregateRequest) (interface{}, error) {
+			return s.Aggregate(ctx, req)
+		})},
+		{MethodName: "Clear", Handler: unaryHandler("Clear", func(s service, ctx context.Context, req *ClearRequest) (interface{}, error) {
+			return s.Clear(ctx, req)
+		})},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(WatchRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(service).Watch(req, stream)
+			},
+		},
+	},
+	Metadata: "grpctrc.proto",
+}
+
+// unaryHandler returns the handler of a unary method that decodes a request of type Req and
+// calls fn with it, through the server's interceptor if there is one.
+func unaryHandler[Req any](method string, fn func(s service, ctx context.Context, req *Req) (interface{}, error)) func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := new(Req)
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return fn(srv.(service), ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/" + method}
+		return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return fn(srv.(service), ctx, req.(*Req))
+		})
+	}
+}
@@ -0,0 +1,155 @@
+//go:build !statetrc_off
+
+package grpctrc
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jeffwilliams/statetrc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient serves t over an in-memory connection and returns a Client for it.
+func newTestClient(t *testing.T, tr *statetrc.Tracer) *Client {
+	l := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	Register(s, tr)
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return NewClient(cc)
+}
+
+func ids(l statetrc.EntrySlice) []string {
+	res := []string{}
+	for _, e := range l {
+		res = append(res, e.Id)
+	}
+	return res
+}
+
+func TestListEntries(t *testing.T) {
+	tr := statetrc.NewTracer()
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tr.SetClock(func() time.Time { return at })
+	tr.Enter("/conn/1", map[string]interface{}{"addr": "10.0.0.1"})
+	tr.Enter("/conn/2", nil)
+	tr.Enter("/job", nil)
+	c := newTestClient(t, tr)
+
+	tests := []struct {
+		query string
+		want  []string
+		code  codes.Code
+	}{
+		{"", []string{"/conn/1", "/conn/2", "/job"}, codes.OK},
+		{"prefix=/conn order=-id", []string{"/conn/2", "/conn/1"}, codes.OK},
+		{"bogus", nil, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			s, err := c.ListEntries(context.Background(), tt.query)
+			if status.Code(err) != tt.code {
+				t.Fatalf("got error %v, want code %v", err, tt.code)
+			}
+			if err == nil && !reflect.DeepEqual(ids(s.Entries), tt.want) {
+				t.Errorf("got %v, want %v", ids(s.Entries), tt.want)
+			}
+			if err == nil && !s.At.Equal(at) {
+				t.Errorf("At = %v, want the time of the snapshot %v", s.At, at)
+			}
+		})
+	}
+
+	s, _ := c.ListEntries(context.Background(), "prefix=/conn/1")
+	if props, ok := s.Entries[0].Props.(map[string]interface{}); !ok || props["addr"] != "10.0.0.1" {
+		t.Errorf("props %#v", s.Entries[0].Props)
+	}
+}
+
+func TestAggregateAndClear(t *testing.T) {
+	tr := statetrc.NewTracer()
+	tr.SetHistorySize(10)
+	tr.Enter("/conn/1", nil)
+	tr.Enter("/conn/2", nil)
+	tr.Enter("/job", nil)
+	c := newTestClient(t, tr)
+	ctx := context.Background()
+
+	stats, err := c.Aggregate(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Prefix != "/conn" || stats[0].Count != 2 {
+		t.Errorf("Aggregate returned %+v", stats)
+	}
+	if _, err := c.Aggregate(ctx, 0); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Aggregate with depth 0 returned %v", err)
+	}
+
+	n, err := c.Clear(ctx, "/conn")
+	if err != nil || n != 2 {
+		t.Errorf("Clear returned %d, %v", n, err)
+	}
+	if got := tr.Count(); got != 1 {
+		t.Errorf("%d entries left, want 1", got)
+	}
+	if h := tr.History(0); len(h) > 0 {
+		t.Errorf("cleared entries were recorded as completed: %v", h)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	tr := statetrc.NewTracer()
+	c := newTestClient(t, tr)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var got []statetrc.EventType
+	done := make(chan error)
+	go func() {
+		done <- c.Watch(ctx, "/conn", func(ev statetrc.Event) bool {
+			if ev.Entry.Id != "/conn/1" {
+				t.Errorf("event for %s", ev.Entry.Id)
+			}
+			got = append(got, ev.Type)
+			// Stop after a complete enter, update and leave.
+			return ev.Type != statetrc.LeaveEvent || len(got) < 3 || got[len(got)-3] != statetrc.EnterEvent
+		})
+	}()
+
+	// Keep changing the entries until the watch has started and seen a complete sequence.
+	for i := 0; ; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []statetrc.EventType{statetrc.EnterEvent, statetrc.UpdateEvent, statetrc.LeaveEvent}
+			if !reflect.DeepEqual(got[len(got)-3:], want) {
+				t.Errorf("got events %v", got)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		tr.Enter("/other", nil)
+		tr.Enter("/conn/1", nil)
+		tr.Update("/conn/1", i)
+		tr.Leave("/conn/1")
+	}
+}
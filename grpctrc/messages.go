@@ -0,0 +1,221 @@
+package grpctrc
+
+import (
+	"time"
+
+	"github.com/jeffwilliams/statetrc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages of grpctrc.proto are encoded by hand, as the messages of the statetrc
+// package are, rather than with generated code.
+
+// ListRequest is the request of ListEntries.
+type ListRequest struct {
+	// Query selects the entries, as for statetrc.Query. Empty selects all entries.
+	Query string
+}
+
+// WatchRequest is the request of Watch.
+type WatchRequest struct {
+	// Prefix is the path prefix of the entries to watch. Empty watches all entries.
+	Prefix string
+}
+
+// AggregateRequest is the request of Aggregate.
+type AggregateRequest struct {
+	// Depth is the number of elements of the ids the entries are grouped by.
+	Depth int
+}
+
+// AggregateResponse is the response of Aggregate.
+type AggregateResponse struct {
+	Stats []statetrc.PrefixStat
+}
+
+// ClearRequest is the request of Clear.
+type ClearRequest struct {
+	// Prefix is the path prefix of the entries to remove. Empty removes all entries.
+	Prefix string
+}
+
+// ClearResponse is the response of Clear.
+type ClearResponse struct {
+	// Removed is the number of entries removed.
+	Removed int
+}
+
+// MarshalProto encodes the request as a ListRequest message of grpctrc.proto.
+func (m *ListRequest) MarshalProto() ([]byte, error) {
+	return appendString(nil, 1, m.Query), nil
+}
+
+// UnmarshalProto decodes a ListRequest message of grpctrc.proto.
+func (m *ListRequest) UnmarshalProto(b []byte) error {
+	*m = ListRequest{}
+	return parse(b, func(num protowire.Number, v uint64, data []byte) {
+		if num == 1 {
+			m.Query = string(data)
+		}
+	})
+}
+
+// MarshalProto encodes the request as a WatchRequest message of grpctrc.proto.
+func (m *WatchRequest) MarshalProto() ([]byte, error) {
+	return appendString(nil, 1, m.Prefix), nil
+}
+
+// UnmarshalProto decodes a WatchRequest message of grpctrc.proto.
+func (m *WatchRequest) UnmarshalProto(b []byte) error {
+	*m = WatchRequest{}
+	return parse(b, func(num protowire.Number, v uint64, data []byte) {
+		if num == 1 {
+			m.Prefix = string(data)
+		}
+	})
+}
+
+// MarshalProto encodes the request as an AggregateRequest message of grpctrc.proto.
+func (m *AggregateRequest) MarshalProto() ([]byte, error) {
+	return appendVarint(nil, 1, uint64(int64(m.Depth))), nil
+}
+
+// UnmarshalProto decodes an AggregateRequest message of grpctrc.proto.
+func (m *AggregateRequest) UnmarshalProto(b []byte) error {
+	*m = AggregateRequest{}
+	return parse(b, func(num protowire.Number, v uint64, data []byte) {
+		if num == 1 {
+			m.Depth = int(int64(v))
+		}
+	})
+}
+
+// MarshalProto encodes the response as an AggregateResponse message of grpctrc.proto.
+func (m *AggregateResponse) MarshalProto() ([]byte, error) {
+	var b, sb []byte
+	for _, s := range m.Stats {
+		sb = appendString(sb[:0], 1, s.Prefix)
+		sb = appendVarint(sb, 2, uint64(int64(s.Count)))
+		sb = appendVarint(sb, 3, uint64(s.Oldest))
+		sb = appendVarint(sb, 4, uint64(s.Newest))
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, sb)
+	}
+	return b, nil
+}
+
+// UnmarshalProto decodes an AggregateResponse message of grpctrc.proto.
+func (m *AggregateResponse) UnmarshalProto(b []byte) error {
+	*m = AggregateResponse{}
+	var err error
+	perr := parse(b, func(num protowire.Number, v uint64, data []byte) {
+		if num != 1 || data == nil {
+			return
+		}
+		var s statetrc.PrefixStat
+		serr := parse(data, func(num protowire.Number, v uint64, data []byte) {
+			switch num {
+			case 1:
+				s.Prefix = string(data)
+			case 2:
+				s.Count = int(int64(v))
+			case 3:
+				s.Oldest = time.Duration(v)
+			case 4:
+				s.Newest = time.Duration(v)
+			}
+		})
+		if err == nil {
+			err = serr
+		}
+		m.Stats = append(m.Stats, s)
+	})
+	if perr != nil {
+		return perr
+	}
+	return err
+}
+
+// MarshalProto encodes the request as a ClearRequest message of grpctrc.proto.
+func (m *ClearRequest) MarshalProto() ([]byte, error) {
+	return appendString(nil, 1, m.Prefix), nil
+}
+
+// UnmarshalProto decodes a ClearRequest message of grpctrc.proto.
+func (m *ClearRequest) UnmarshalProto(b []byte) error {
+	*m = ClearRequest{}
+	return parse(b, func(num protowire.Number, v uint64, data []byte) {
+		if num == 1 {
+			m.Prefix = string(data)
+		}
+	})
+}
+
+// MarshalProto encodes the response as a ClearResponse message of grpctrc.proto.
+func (m *ClearResponse) MarshalProto() ([]byte, error) {
+	return appendVarint(nil, 1, uint64(int64(m.Removed))), nil
+}
+
+// UnmarshalProto decodes a ClearResponse message of grpctrc.proto.
+func (m *ClearResponse) UnmarshalProto(b []byte) error {
+	*m = ClearResponse{}
+	return parse(b, func(num protowire.Number, v uint64, data []byte) {
+		if num == 1 {
+			m.Removed = int(int64(v))
+		}
+	})
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// parse calls fn for each varint and length-delimited field of the message b, with the value
+// in v or data respectively. Other fields are skipped.
+func parse(b []byte, fn func(num protowire.Number, v uint64, data []byte)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fn(num, v, nil)
+			b = b[n:]
+		case protowire.BytesType:
+			data, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if data == nil {
+				data = []byte{}
+			}
+			fn(num, 0, data)
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return nil
+}
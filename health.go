@@ -0,0 +1,94 @@
+package statetrc
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Rule limits the age of the entries whose ids match a glob pattern, for HealthHandler.
+type Rule struct {
+	// Pattern is a glob pattern for ids, as for ListGlob.
+	Pattern string
+	// MaxAge is the age that matching entries must stay below.
+	MaxAge time.Duration
+}
+
+// ParseRule parses a Rule written as a pattern and a duration separated by '<', such as
+// "/request/* < 30s".
+func ParseRule(s string) (Rule, error) {
+	pattern, limit, ok := strings.Cut(s, "<")
+	if !ok {
+		return Rule{}, fmt.Errorf("statetrc: bad rule %q: expected PATTERN < DURATION", s)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(limit))
+	if err != nil {
+		return Rule{}, fmt.Errorf("statetrc: bad rule %q: %w", s, err)
+	}
+	r := Rule{Pattern: strings.TrimSpace(pattern), MaxAge: d}
+	if _, err := compileGlob(r.Pattern); err != nil {
+		return Rule{}, err
+	}
+	return r, nil
+}
+
+func (r Rule) String() string {
+	return fmt.Sprintf("%s < %v", r.Pattern, r.MaxAge)
+}
+
+// HealthHandler returns an http.Handler for health checks that fails when entries are stuck.
+// It responds with status 200 and "ok" if no entry violates the rules, and otherwise with
+// status 503 and the offending entries, oldest first, in the format selected as for Handler.
+// This lets orchestrators restart processes that are genuinely wedged. If a rule's pattern is
+// malformed the handler responds with status 500.
+func (t *Tracer) HealthHandler(rules ...Rule) http.Handler {
+	globs := make([]glob, len(rules))
+	var err error
+	for i, r := range rules {
+		if globs[i], err = compileGlob(t.full(r.Pattern)); err != nil {
+			break
+		}
+	}
+
+	return t.authorize(func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		f, ctype, ferr := requestFormatter(r)
+		if ferr != nil {
+			http.Error(w, ferr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var now time.Time
+		s := t.snapshot(func(e *Entry) bool {
+			if now.IsZero() {
+				now = t.nowLocked()
+			}
+			for i, g := range globs {
+				if e.Age(now) >= rules[i].MaxAge && g.match(e.Id) {
+					return true
+				}
+			}
+			return false
+		}, ByAge)
+
+		w.Header().Set("Cache-Control", "no-cache")
+		if len(s.Entries) == 0 {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			io.WriteString(w, "ok\n")
+			return
+		}
+		w.Header().Set("Content-Type", ctype)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		s.Render(w, f)
+	})
+}
+
+// HealthHandler calls HealthHandler on the default Tracer.
+func HealthHandler(rules ...Rule) http.Handler {
+	return std.HealthHandler(rules...)
+}
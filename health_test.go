@@ -0,0 +1,58 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Rule
+		ok   bool
+	}{
+		{"/request/* < 30s", Rule{"/request/*", 30 * time.Second}, true},
+		{"/db<1m", Rule{"/db", time.Minute}, true},
+		{"/db", Rule{}, false},
+		{"/db < soon", Rule{}, false},
+		{"/db/[ < 1s", Rule{}, false},
+	}
+	for _, tt := range tests {
+		r, err := ParseRule(tt.s)
+		if (err == nil) != tt.ok || r != tt.want {
+			t.Errorf("ParseRule(%q) = %v, %v", tt.s, r, err)
+		}
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	tests := []struct {
+		rule string
+		code int
+		body string
+	}{
+		{"/conn/* < 90s", 503, "/conn/1"},
+		{"/conn/* < 1h", 200, "ok\n"},
+		{"/job < 2s", 200, "ok\n"},
+		{"/conn/3/* < 1ms", 503, "/conn/3/read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := get(newQueryTracer().HealthHandler(r), "/")
+			if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body, tt.code, tt.body)
+			}
+		})
+	}
+
+	if w := get(NewTracer().HealthHandler(Rule{Pattern: "[", MaxAge: time.Second}), "/"); w.Code != 500 {
+		t.Errorf("bad pattern: status %d, want 500", w.Code)
+	}
+}
@@ -0,0 +1,111 @@
+package statetrc
+
+import "time"
+
+// HighWaterMark holds the numbers of entries that existed under a prefix at the same time.
+type HighWaterMark struct {
+	// Current is the number of entries now
+	Current int
+	// Max is the largest number of entries since tracking was enabled, reached at MaxTime
+	Max     int
+	MaxTime time.Time
+	// RecentMax is the largest number of entries since ResetHighWater was called, or since
+	// tracking was enabled if it was not, reached at RecentMaxTime
+	RecentMax     int
+	RecentMaxTime time.Time
+}
+
+// add adds delta to the current number of entries at now.
+func (m *HighWaterMark) add(delta int, now time.Time) {
+	m.Current += delta
+	if m.Current > m.Max {
+		m.Max, m.MaxTime = m.Current, now
+	}
+	if m.Current > m.RecentMax {
+		m.RecentMax, m.RecentMaxTime = m.Current, now
+	}
+}
+
+// SetHighWaterDepth enables tracking of the largest numbers of entries that existed at the
+// same time under each prefix of up to depth elements, including the empty prefix for all
+// entries, which is useful for sizing pools and spotting load spikes that have since drained.
+// Passing a negative depth disables tracking. Changing the depth restarts tracking from the
+// current entries.
+func (t *Tracer) SetHighWaterDepth(depth int) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.highWater = nil
+	t.highWaterDepth = depth
+	if depth < 0 {
+		return
+	}
+	t.highWater = map[string]*HighWaterMark{}
+	now := t.nowLocked()
+	for id := range t.entries {
+		t.countHighWaterLocked(id, 1, now)
+	}
+}
+
+// SetHighWaterDepth calls SetHighWaterDepth on the default Tracer.
+func SetHighWaterDepth(depth int) {
+	std.SetHighWaterDepth(depth)
+}
+
+// HighWater returns the high-water mark of the entries under the path prefix. It is zero if the
+// prefix has more elements than the depth set by SetHighWaterDepth, or has never had entries.
+func (t *Tracer) HighWater(prefix string) HighWaterMark {
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	if m, ok := t.highWater[prefix]; ok {
+		return *m
+	}
+	return HighWaterMark{}
+}
+
+// HighWater calls HighWater on the default Tracer.
+func HighWater(prefix string) HighWaterMark {
+	return std.HighWater(prefix)
+}
+
+// ResetHighWater sets the RecentMax of every prefix to its current number of entries. For a
+// namespace only the prefixes under its prefix are reset.
+func (t *Tracer) ResetHighWater() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	now := t.nowLocked()
+	for p, m := range t.highWater {
+		if t.inScope(p) {
+			m.RecentMax, m.RecentMaxTime = m.Current, now
+		}
+	}
+}
+
+// ResetHighWater calls ResetHighWater on the default Tracer.
+func ResetHighWater() {
+	std.ResetHighWater()
+}
+
+// countHighWaterLocked adds delta to the number of entries under each tracked prefix of id.
+// t.mtx must be held.
+func (c *core) countHighWaterLocked(id string, delta int, now time.Time) {
+	if c.highWater == nil {
+		return
+	}
+	last := ""
+	for d := 0; d <= c.highWaterDepth; d++ {
+		p := idPrefix(id, d)
+		if d > 0 && p == last {
+			// id has fewer than d elements.
+			break
+		}
+		last = p
+		m, ok := c.highWater[p]
+		if !ok {
+			m = &HighWaterMark{}
+			c.highWater[p] = m
+		}
+		m.add(delta, now)
+	}
+}
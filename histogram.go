@@ -0,0 +1,151 @@
+package statetrc
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultBuckets are bucket bounds suitable for coarse program states, for SetHistogramBuckets.
+var DefaultBuckets = []time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 500 * time.Millisecond, time.Second, 5 * time.Second,
+	10 * time.Second, 30 * time.Second, time.Minute, 5 * time.Minute,
+}
+
+// DurationHistogram counts the durations of completed entries in buckets.
+type DurationHistogram struct {
+	// Bounds are the upper bounds of the buckets, in increasing order
+	Bounds []time.Duration
+	// Counts holds the number of durations in each bucket. Counts[i] is the number of
+	// durations greater than Bounds[i-1] and at most Bounds[i], and the last element is the
+	// number of durations greater than all Bounds.
+	Counts []uint64
+	// Count is the number of durations
+	Count uint64
+	// Sum is the total of the durations
+	Sum time.Duration
+}
+
+func (h *DurationHistogram) add(d time.Duration, bounds []time.Duration) {
+	if h.Counts == nil {
+		*h = DurationHistogram{Bounds: bounds, Counts: make([]uint64, len(bounds)+1)}
+	}
+	i := sort.Search(len(bounds), func(i int) bool { return d <= bounds[i] })
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+func (h *DurationHistogram) merge(o *DurationHistogram) {
+	if o.Counts == nil {
+		return
+	}
+	if h.Counts == nil {
+		*h = DurationHistogram{Bounds: o.Bounds, Counts: make([]uint64, len(o.Counts))}
+	}
+	for i, n := range o.Counts {
+		h.Counts[i] += n
+	}
+	h.Count += o.Count
+	h.Sum += o.Sum
+}
+
+// copy returns a copy of h that does not share its Counts.
+func (h DurationHistogram) copy() DurationHistogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// Quantile returns an estimate of the q-quantile of the durations, for q between 0 and 1, such
+// as 0.99 for the 99th percentile. As for Prometheus histograms the duration is interpolated
+// linearly within the bucket holding it, and durations beyond the largest bound are reported as
+// that bound. It returns zero if the histogram is empty.
+func (h DurationHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := q * float64(h.Count)
+	var seen float64
+	for i, n := range h.Counts {
+		if n == 0 || seen+float64(n) < rank {
+			seen += float64(n)
+			continue
+		}
+		if i == len(h.Bounds) {
+			break
+		}
+		var lower time.Duration
+		if i > 0 {
+			lower = h.Bounds[i-1]
+		}
+		frac := (rank - seen) / float64(n)
+		return lower + time.Duration(frac*float64(h.Bounds[i]-lower))
+	}
+	if len(h.Bounds) == 0 {
+		return 0
+	}
+	return h.Bounds[len(h.Bounds)-1]
+}
+
+// SetHistogramBuckets enables keeping a DurationHistogram of the durations of completed entries
+// for each group of entries whose statistics are kept, as set by SetStatsDepth, with buckets
+// whose upper bounds are bounds, such as DefaultBuckets. The histograms provide the percentiles
+// of DurationStats. Passing no bounds disables the histograms. Changing the buckets discards the
+// histograms collected so far.
+func (t *Tracer) SetHistogramBuckets(bounds ...time.Duration) {
+	bounds = append([]time.Duration(nil), bounds...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.buckets = bounds
+	for _, s := range t.stats {
+		s.hist = DurationHistogram{}
+	}
+}
+
+// SetHistogramBuckets calls SetHistogramBuckets on the default Tracer.
+func SetHistogramBuckets(bounds ...time.Duration) {
+	std.SetHistogramBuckets(bounds...)
+}
+
+// Histogram returns the combined histograms of the groups of completed entries that are under
+// the path prefix, as for Stats. It is empty if SetHistogramBuckets was not called.
+func (t *Tracer) Histogram(prefix string) DurationHistogram {
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireStatsLocked()
+	var res DurationHistogram
+	for key, s := range t.stats {
+		if hasPathPrefix(key, prefix) {
+			res.merge(&s.hist)
+		}
+	}
+	return res.copy()
+}
+
+// Histogram calls Histogram on the default Tracer.
+func Histogram(prefix string) DurationHistogram {
+	return std.Histogram(prefix)
+}
+
+// AllHistograms returns the histogram of each group of completed entries, keyed by the prefix
+// of the group, as AllStats does.
+func (t *Tracer) AllHistograms() map[string]DurationHistogram {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireStatsLocked()
+	res := map[string]DurationHistogram{}
+	for key, s := range t.stats {
+		if t.inScope(key) && s.hist.Count > 0 {
+			res[key] = s.hist.copy()
+		}
+	}
+	return res
+}
+
+// AllHistograms calls AllHistograms on the default Tracer.
+func AllHistograms() map[string]DurationHistogram {
+	return std.AllHistograms()
+}
@@ -0,0 +1,119 @@
+package statetrc
+
+// ring is a bounded buffer that keeps the most recently added values.
+type ring[T any] struct {
+	buf  []T
+	next int
+	full bool
+}
+
+// resize sets the capacity of the ring to n, discarding its contents.
+func (r *ring[T]) resize(n int) {
+	*r = ring[T]{}
+	if n > 0 {
+		r.buf = make([]T, n)
+	}
+}
+
+func (r *ring[T]) add(v T) {
+	if len(r.buf) == 0 {
+		return
+	}
+	r.buf[r.next] = v
+	r.next++
+	if r.next == len(r.buf) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// list returns the values in the ring, oldest first.
+func (r *ring[T]) list() []T {
+	if !r.full {
+		return append([]T(nil), r.buf[:r.next]...)
+	}
+	res := make([]T, 0, len(r.buf))
+	res = append(res, r.buf[r.next:]...)
+	return append(res, r.buf[:r.next]...)
+}
+
+// filter removes the values for which keep returns false.
+func (r *ring[T]) filter(keep func(v T) bool) {
+	l := r.list()
+	r.resize(len(r.buf))
+	for _, v := range l {
+		if keep(v) {
+			r.add(v)
+		}
+	}
+}
+
+// SetHistorySize enables recording of up to n completed entries, that is entries that were left,
+// with their EndTime set. The oldest completed entries are discarded when more than n have been
+// recorded. Passing zero disables recording. Changing the size discards the recorded entries.
+func (t *Tracer) SetHistorySize(n int) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.history.resize(n)
+}
+
+// SetHistorySize calls SetHistorySize on the default Tracer.
+func SetHistorySize(n int) {
+	std.SetHistorySize(n)
+}
+
+// History returns the n most recently completed entries recorded since SetHistorySize was
+// called, oldest first, or all of them if n is zero or less. Each has the id, Time, EndTime
+// and props of a state that was left, so it answers what just finished and how long it took,
+// which Age returns for completed entries.
+func (t *Tracer) History(n int) EntrySlice {
+	l := t.completed()
+	if n > 0 && n < len(l) {
+		l = l[len(l)-n:]
+	}
+	return l
+}
+
+// History calls History on the default Tracer.
+func History(n int) EntrySlice {
+	return std.History(n)
+}
+
+// historyEnabled reports whether completed entries are recorded.
+func (t *Tracer) historyEnabled() bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if len(t.history.buf) > 0 {
+		return true
+	}
+	for _, r := range t.retention {
+		if r.HistorySize > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// completeLocked records the completed entry e. t.mtx must be held.
+func (c *core) completeLocked(e Entry) {
+	if c.sampledLocked(e) {
+		c.addHistoryLocked(e)
+		c.recordStatsLocked(e)
+	}
+	c.leaveHookLocked(e)
+	c.sendCompletedLocked(e)
+}
+
+// completed returns the recorded completed entries visible through t, oldest first.
+func (t *Tracer) completed() EntrySlice {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	l := t.historyLocked()
+	res := l[:0]
+	for _, e := range l {
+		if t.inScope(e.Id) {
+			res = append(res, t.redactLocked(e))
+		}
+	}
+	return res
+}
@@ -0,0 +1,81 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.SetHistorySize(2)
+	for _, id := range []string{"/a", "/b", "/c"} {
+		tr.Enter(id, nil)
+		clock.Add(time.Second)
+		tr.Leave(id)
+	}
+
+	h := tr.History(0)
+	if got, want := ids(h), []string{"/b", "/c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("History = %v, want %v", got, want)
+	}
+	if d := h[1].EndTime.Sub(h[1].Time); d != time.Second {
+		t.Errorf("duration of /c = %v, want 1s", d)
+	}
+	if got, want := ids(tr.History(1)), []string{"/c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("History(1) = %v, want %v", got, want)
+	}
+	tr.ResetHistory()
+	if h := tr.History(0); len(h) > 0 {
+		t.Errorf("after ResetHistory got %v", ids(h))
+	}
+}
+
+func TestRetention(t *testing.T) {
+	tr := NewTracer()
+	tr.SetHistorySize(1)
+	tr.SetRetention("/rare", Retention{HistorySize: 2})
+	for _, id := range []string{"/rare/a", "/rare/b", "/x", "/y"} {
+		tr.Enter(id, nil)
+		tr.Leave(id)
+	}
+	if got, want := ids(tr.History(0)), []string{"/rare/a", "/rare/b", "/y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("History = %v, want %v", got, want)
+	}
+
+	tr.Namespace("/rare").ResetHistory()
+	if got, want := ids(tr.History(0)), []string{"/y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after the namespace ResetHistory got %v, want %v", got, want)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		slow time.Duration
+		// min and max bound the number of the 100 entries that are recorded
+		min, max int
+	}{
+		{"all", 1, 0, 100, 100},
+		{"sampled", 1000, 0, 0, 10},
+		{"slow kept", 1000, time.Second, 100, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, clock := newTestTracer()
+			tr.SetHistorySize(100)
+			tr.SetSampling(tt.n, tt.slow)
+			for i := 0; i < 100; i++ {
+				tr.Enter("/a", nil)
+				clock.Add(time.Second)
+				tr.Leave("/a")
+			}
+			if n := len(tr.History(0)); n < tt.min || n > tt.max {
+				t.Errorf("recorded %d entries, want %d to %d", n, tt.min, tt.max)
+			}
+		})
+	}
+}
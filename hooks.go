@@ -0,0 +1,139 @@
+package statetrc
+
+import (
+	"fmt"
+	"time"
+)
+
+// hookCall is a call of the hooks that has been delayed until t.mtx is unlocked.
+type hookCall struct {
+	leave bool
+	e     Entry
+	d     time.Duration
+}
+
+// OnEnter registers fn to be called with the entry each time Enter or one of its variants adds
+// an entry, or increments the Count of one, so that metrics, logging or custom policies can be
+// attached without changing the package. For a namespace only entries under its prefix are
+// reported. It returns a function that unregisters fn.
+//
+// Hooks are called in the order they were registered, by the goroutine that entered the state
+// but after the Tracer has been unlocked, so they may call methods of the Tracer. A panic in a
+// hook is recovered and reported to the function set by SetStrict, if any, so it does not
+// affect the traced code or the other hooks.
+func (t *Tracer) OnEnter(fn func(e Entry)) func() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	h := &enterHook{prefix: t.prefix, fn: fn}
+	t.onEnter = append(t.onEnter, h)
+	return func() {
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+		t.onEnter = removeHook(t.onEnter, h)
+	}
+}
+
+// OnEnter calls OnEnter on the default Tracer.
+func OnEnter(fn func(e Entry)) func() {
+	return std.OnEnter(fn)
+}
+
+// OnLeave registers fn to be called with each completed entry and its duration when its state
+// is left, either by Leave and its variants or by LeavePrefix. The entry has its EndTime set.
+// Entries that expire or are removed by Clear are not reported, nor are decrements of the Count
+// of an entry in RefCount mode. It returns a function that unregisters fn. Hooks are called as
+// described for OnEnter.
+func (t *Tracer) OnLeave(fn func(e Entry, d time.Duration)) func() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	h := &leaveHook{prefix: t.prefix, fn: fn}
+	t.onLeave = append(t.onLeave, h)
+	return func() {
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+		t.onLeave = removeHook(t.onLeave, h)
+	}
+}
+
+// OnLeave calls OnLeave on the default Tracer.
+func OnLeave(fn func(e Entry, d time.Duration)) func() {
+	return std.OnLeave(fn)
+}
+
+// enterHook and leaveHook are hooks registered with OnEnter and OnLeave. prefix is the prefix
+// of the namespace they were registered with.
+type enterHook struct {
+	prefix string
+	fn     func(e Entry)
+}
+
+type leaveHook struct {
+	prefix string
+	fn     func(e Entry, d time.Duration)
+}
+
+// removeHook returns l without h. It does not modify l, since l may be in use by hooks that
+// are running.
+func removeHook[H comparable](l []H, h H) []H {
+	for i, o := range l {
+		if o == h {
+			return append(l[:i:i], l[i+1:]...)
+		}
+	}
+	return l
+}
+
+// enterHookLocked arranges for the OnEnter hooks to be called with e when t.mtx is unlocked.
+// t.mtx must be held.
+func (c *core) enterHookLocked(e Entry) {
+	if len(c.onEnter) > 0 {
+		c.hookCalls = append(c.hookCalls, hookCall{e: c.redactLocked(e)})
+	}
+}
+
+// leaveHookLocked arranges for the OnLeave hooks to be called with the completed entry e when
+// t.mtx is unlocked. t.mtx must be held.
+func (c *core) leaveHookLocked(e Entry) {
+	if len(c.onLeave) > 0 {
+		c.hookCalls = append(c.hookCalls, hookCall{leave: true, e: c.redactLocked(e), d: e.EndTime.Sub(e.Time)})
+	}
+}
+
+// unlock unlocks t.mtx and then calls the hooks for the changes made while it was held.
+func (c *core) unlock() {
+	if len(c.hookCalls) == 0 {
+		c.mtx.Unlock()
+		return
+	}
+	calls, enter, leave, misuse := c.hookCalls, c.onEnter, c.onLeave, c.misuse
+	c.hookCalls = nil
+	c.mtx.Unlock()
+
+	for _, call := range calls {
+		if call.leave {
+			for _, h := range leave {
+				if h.prefix != "" && !hasPathPrefix(call.e.Id, h.prefix) {
+					continue
+				}
+				runHook(misuse, func() { h.fn(call.e, call.d) })
+			}
+		} else {
+			for _, h := range enter {
+				if h.prefix != "" && !hasPathPrefix(call.e.Id, h.prefix) {
+					continue
+				}
+				runHook(misuse, func() { h.fn(call.e) })
+			}
+		}
+	}
+}
+
+// runHook calls fn, recovering from a panic and reporting it to misuse if it is not nil.
+func runHook(misuse func(err error), fn func()) {
+	defer func() {
+		if r := recover(); r != nil && misuse != nil {
+			misuse(fmt.Errorf("statetrc: hook panicked: %v", r))
+		}
+	}()
+	fn()
+}
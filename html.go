@@ -0,0 +1,110 @@
+package statetrc
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+// htmlReport is the template for WriteHTML. It has no external assets so that the page can be
+// attached to tickets and viewed offline.
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>statetrc snapshot {{.At.Format "2006-01-02 15:04:05"}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; vertical-align: top; }
+th { cursor: pointer; background: #eee; }
+td.num { text-align: right; }
+pre { margin: 0; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>statetrc snapshot</h1>
+<p>Captured {{.At.Format "2006-01-02 15:04:05.000 MST"}}, {{len .Rows}} entries.</p>
+<p><input id="filter" type="search" placeholder="Filter" size="40"></p>
+<table id="entries">
+<thead><tr><th>Id</th><th>Age</th><th>Count</th><th>Start</th><th>Props</th></tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr><td>{{.Id}}</td><td class="num" data-sort="{{.AgeNs}}">{{.Age}}</td><td class="num" data-sort="{{.Count}}">{{.Count}}</td><td>{{.Start}}</td><td><pre>{{.Props}}</pre></td></tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+(function() {
+  var table = document.getElementById("entries");
+  var body = table.tBodies[0];
+  var dir = {};
+  function key(row, col) {
+    var cell = row.cells[col];
+    var v = cell.getAttribute("data-sort");
+    return v === null ? cell.textContent : Number(v);
+  }
+  Array.prototype.forEach.call(table.tHead.rows[0].cells, function(th, col) {
+    th.addEventListener("click", function() {
+      dir[col] = dir[col] === 1 ? -1 : 1;
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function(a, b) {
+        var x = key(a, col), y = key(b, col);
+        return (x < y ? -1 : x > y ? 1 : 0) * dir[col];
+      });
+      rows.forEach(function(r) { body.appendChild(r); });
+    });
+  });
+  document.getElementById("filter").addEventListener("input", function(ev) {
+    var q = ev.target.value.toLowerCase();
+    Array.prototype.forEach.call(body.rows, function(r) {
+      r.style.display = r.textContent.toLowerCase().indexOf(q) >= 0 ? "" : "none";
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
+
+type htmlRow struct {
+	Id    string
+	Age   string
+	AgeNs int64
+	Count int
+	Start string
+	Props string
+}
+
+// WriteHTML writes the Snapshot to w as a self-contained HTML page with a table of the entries
+// that can be sorted by clicking the column headings and filtered with a search box. The page
+// has no external assets, so it is suitable for attaching to incident tickets.
+func (s Snapshot) WriteHTML(w io.Writer) error {
+	data := struct {
+		At   time.Time
+		Rows []htmlRow
+	}{At: s.At}
+
+	for _, e := range s.Entries {
+		age := e.Age(s.At)
+		data.Rows = append(data.Rows, htmlRow{
+			Id:    e.Id,
+			Age:   DefaultFormatOptions.duration(age),
+			AgeNs: int64(age),
+			Count: e.Count,
+			Start: DefaultFormatOptions.start(e.Time),
+			Props: propsString(e, &DefaultFormatOptions),
+		})
+	}
+	return htmlReport.Execute(w, data)
+}
+
+// WriteHTML writes the currently existing entries to w as an HTML page. See Snapshot.WriteHTML.
+func (t *Tracer) WriteHTML(w io.Writer) error {
+	return t.Capture(ById).WriteHTML(w)
+}
+
+// WriteHTML calls WriteHTML on the default Tracer.
+func WriteHTML(w io.Writer) error {
+	return std.WriteHTML(w)
+}
@@ -0,0 +1,155 @@
+package statetrc
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Handler returns an http.Handler that serves a Snapshot of the entries, for debugging servers
+// in the manner of net/http/pprof. Entries are selected with these URL query parameters:
+//
+//	prefix=P       ids under the path prefix P
+//	glob=G         ids matching the glob pattern G
+//	match=RE       ids matching the regular expression RE
+//	label.K=V      entries whose label K has the value V
+//	min-age=D      entries older than the duration D
+//	max-age=D      entries younger than the duration D
+//	order=O        the order of the entries, as for Query
+//	offset=N       skip the first N entries
+//	limit=N        return at most N entries
+//	q=Q            the terms of the Query string Q
+//
+// The entries are written as text by default, or as JSON if the request's Accept header
+// contains application/json. The format parameter selects the output explicitly and is one of
+// text, json, yaml, table, tree, markdown or html.
+func (t *Tracer) Handler() http.Handler {
+	return t.authorize(t.serveEntries)
+}
+
+// Handler calls Handler on the default Tracer.
+func Handler() http.Handler {
+	return std.Handler()
+}
+
+// serveEntries serves the entries selected by the query parameters of r, for Handler.
+func (t *Tracer) serveEntries(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQueryParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f, ctype, err := requestFormatter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Cache-Control", "no-cache")
+	t.runQuery(q).Render(w, f)
+}
+
+// HistoryHandler returns an http.Handler that serves the completed entries recorded since
+// SetHistorySize was called, oldest first. It accepts the same parameters as Handler, with
+// ages being the durations of the entries.
+func (t *Tracer) HistoryHandler() http.Handler {
+	return t.authorize(func(w http.ResponseWriter, r *http.Request) {
+		q, err := parseQueryParams(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f, ctype, err := requestFormatter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s := Snapshot{At: t.Now()}
+		keep := t.queryFilter(q, func() time.Time { return s.At })
+		for _, e := range t.completed() {
+			if keep(&e) {
+				s.Entries = append(s.Entries, e)
+			}
+		}
+		if q.order != nil {
+			sort.Slice(s.Entries, q.order(s.Entries))
+		}
+		s.Entries = page(s.Entries, q.offset, q.limit)
+
+		w.Header().Set("Content-Type", ctype)
+		w.Header().Set("Cache-Control", "no-cache")
+		s.Render(w, f)
+	})
+}
+
+// HistoryHandler calls HistoryHandler on the default Tracer.
+func HistoryHandler() http.Handler {
+	return std.HistoryHandler()
+}
+
+// parseQueryParams returns the query selected by the URL query parameters described for Handler.
+func parseQueryParams(params url.Values) (*query, error) {
+	q, err := parseQuery(params.Get("q"))
+	if err != nil {
+		return nil, err
+	}
+	for key, vals := range params {
+		var term string
+		switch {
+		case key == "q" || key == "format":
+			continue
+		case key == "min-age":
+			term = "age>" + vals[0]
+		case key == "max-age":
+			term = "age<" + vals[0]
+		case key == "prefix", key == "glob", key == "match", key == "order", key == "offset",
+			key == "limit", strings.HasPrefix(key, "label."):
+			term = key + "=" + vals[0]
+		default:
+			return nil, fmt.Errorf("statetrc: unknown parameter %q", key)
+		}
+		if err := q.parseTerm(term); err != nil {
+			return nil, fmt.Errorf("statetrc: bad parameter %s=%q: %w", key, vals[0], err)
+		}
+	}
+	return q, nil
+}
+
+// requestFormatter returns the Formatter and content type for r, as described for Handler.
+func requestFormatter(r *http.Request) (Formatter, string, error) {
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "application/json") {
+		format = "json"
+	}
+
+	switch format {
+	case "", "text":
+		return TextFormatter{}, "text/plain; charset=utf-8", nil
+	case "json":
+		return JSONFormatter{Indent: "  "}, "application/json", nil
+	case "yaml":
+		return YAMLFormatter{}, "application/yaml", nil
+	case "table":
+		return TableFormatter{}, "text/plain; charset=utf-8", nil
+	case "tree":
+		return TreeFormatter{}, "text/plain; charset=utf-8", nil
+	case "markdown":
+		return MarkdownFormatter{}, "text/markdown; charset=utf-8", nil
+	case "html":
+		return htmlFormatter{}, "text/html; charset=utf-8", nil
+	}
+	return nil, "", fmt.Errorf("statetrc: unknown format %q", format)
+}
+
+// htmlFormatter is a Formatter that writes the report of Snapshot.WriteHTML.
+type htmlFormatter struct{}
+
+func (htmlFormatter) Format(w io.Writer, s Snapshot) error {
+	return s.WriteHTML(w)
+}
@@ -0,0 +1,116 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// get serves a GET request for target with h and returns the recorded response.
+func get(h http.Handler, target string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		target string
+		header []string
+		code   int
+		ctype  string
+		body   string
+	}{
+		{"/", nil, 200, "text/plain; charset=utf-8", "/conn/1"},
+		{"/?format=json", nil, 200, "application/json", `"id": "/conn/1"`},
+		{"/", []string{"Accept", "application/json"}, 200, "application/json", `"id": "/job"`},
+		{"/?format=yaml", nil, 200, "application/yaml", "  - id: /conn/1\n"},
+		{"/?format=markdown", nil, 200, "text/markdown; charset=utf-8", "/conn/1"},
+		{"/?format=html", nil, 200, "text/html; charset=utf-8", "/conn/1"},
+		{"/?format=tree", nil, 200, "text/plain; charset=utf-8", "read"},
+		{"/?prefix=/job", nil, 200, "text/plain; charset=utf-8", "/job"},
+		{"/?format=bogus", nil, 400, "", "unknown format"},
+		{"/?bogus=1", nil, 400, "", "unknown parameter"},
+		{"/?limit=x", nil, 400, "", "bad parameter limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := get(newQueryTracer().Handler(), tt.target, tt.header...)
+			if w.Code != tt.code {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); tt.ctype != "" && got != tt.ctype {
+				t.Errorf("Content-Type %q, want %q", got, tt.ctype)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("body does not contain %q:\n%s", tt.body, w.Body)
+			}
+		})
+	}
+}
+
+func TestHandlerSelection(t *testing.T) {
+	w := get(newQueryTracer().Handler(), "/?format=json&prefix=/conn&min-age=30s&order=-id")
+	var s Snapshot
+	if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(ids(s.Entries), " "), "/conn/2 /conn/1"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestHistoryHandler(t *testing.T) {
+	tr := NewTracer()
+	tr.SetHistorySize(10)
+	tr.Enter("/a", nil)
+	tr.Leave("/a")
+	tr.Enter("/b", nil)
+
+	w := get(tr.HistoryHandler(), "/?format=json")
+	var s Snapshot
+	if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Entries) != 1 || s.Entries[0].Id != "/a" || s.Entries[0].EndTime.IsZero() {
+		t.Errorf("got %v", s.Entries)
+	}
+}
+
+func TestAuthorizer(t *testing.T) {
+	tests := []struct {
+		name   string
+		auth   Authorizer
+		header []string
+		code   int
+	}{
+		{"none", nil, nil, 200},
+		{"token", TokenAuthorizer("secret"), []string{"Authorization", "Bearer secret"}, 200},
+		{"wrong token", TokenAuthorizer("secret"), []string{"Authorization", "Bearer guess"}, 401},
+		{"no token", TokenAuthorizer("secret"), nil, 401},
+		{"forbidden", func(*http.Request) error { return errors.New("no") }, nil, 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTracer()
+			tr.SetAuthorizer(tt.auth)
+			for _, h := range []http.Handler{tr.Handler(), tr.APIHandler(), tr.HistoryHandler(), tr.StatusHandler()} {
+				if w := get(h, "/", tt.header...); w.Code != tt.code {
+					t.Errorf("status %d, want %d", w.Code, tt.code)
+				}
+			}
+			if w := get(tr.Handler(), "/"); tt.code == 401 && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("no WWW-Authenticate header")
+			}
+		})
+	}
+}
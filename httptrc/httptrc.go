@@ -0,0 +1,113 @@
+// Package httptrc serves the entries of the default statetrc Tracer over HTTP. Like
+// net/http/pprof it is usually imported only for the side effect of registering its handlers:
+//
+//	import _ "github.com/jeffwilliams/statetrc/httptrc"
+//
+// The handlers are registered on http.DefaultServeMux under /debug/statetrc/:
+//
+//	/debug/statetrc/           the entries as text
+//	/debug/statetrc/tree       the entries as a tree
+//	/debug/statetrc/json       the entries as JSON
+//	/debug/statetrc/api        the entries or statistics about them as structured JSON
+//	/debug/statetrc/history    the completed entries, if enabled with statetrc.SetHistorySize
+//	/debug/statetrc/events     a stream of changes as Server-Sent Events
+//	/debug/statetrc/ws         a stream of changes over a WebSocket
+//	/debug/statetrc/ui         a live dashboard page
+//	/debug/statetrc/status     a status page summarizing the entries and recent completions
+//	/debug/statetrc/anomalies  the entries that are unusually old, see statetrc.Anomalies
+//
+// The first three and history accept the query parameters described for statetrc.Handler. The
+// others are served by statetrc.APIHandler, statetrc.SSEHandler, statetrc.WebSocketHandler,
+// statetrc.DashboardHandler, statetrc.StatusHandler and statetrc.AnomalyHandler.
+//
+// Access to all of them can be restricted with statetrc.SetAuthorizer.
+//
+// If you are not using DefaultServeMux, register the handlers with the mux you are using.
+package httptrc
+
+import (
+	"net/http"
+
+	"github.com/jeffwilliams/statetrc"
+)
+
+func init() {
+	http.HandleFunc("/debug/statetrc/", Index)
+	http.HandleFunc("/debug/statetrc/tree", Tree)
+	http.HandleFunc("/debug/statetrc/json", JSON)
+	http.HandleFunc("/debug/statetrc/api", API)
+	http.HandleFunc("/debug/statetrc/history", History)
+	http.HandleFunc("/debug/statetrc/events", Events)
+	http.HandleFunc("/debug/statetrc/ws", WebSocket)
+	http.HandleFunc("/debug/statetrc/ui", Dashboard)
+	http.HandleFunc("/debug/statetrc/status", Status)
+	http.HandleFunc("/debug/statetrc/anomalies", Anomalies)
+}
+
+// Index serves the entries as text, or in the format selected by the format parameter.
+// Paths below /debug/statetrc/ other than those of the other handlers are not found.
+func Index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/debug/statetrc/" {
+		http.NotFound(w, r)
+		return
+	}
+	statetrc.Handler().ServeHTTP(w, r)
+}
+
+// Tree serves the entries as a tree, as written by statetrc.TreeFormatter.
+func Tree(w http.ResponseWriter, r *http.Request) {
+	statetrc.Handler().ServeHTTP(w, withFormat(r, "tree"))
+}
+
+// JSON serves the entries as JSON.
+func JSON(w http.ResponseWriter, r *http.Request) {
+	statetrc.Handler().ServeHTTP(w, withFormat(r, "json"))
+}
+
+// API serves the entries as structured JSON, as described for statetrc.APIHandler.
+func API(w http.ResponseWriter, r *http.Request) {
+	statetrc.APIHandler().ServeHTTP(w, r)
+}
+
+// History serves the completed entries as text, or in the format selected by the format parameter.
+func History(w http.ResponseWriter, r *http.Request) {
+	statetrc.HistoryHandler().ServeHTTP(w, r)
+}
+
+// Events streams changes to the entries as Server-Sent Events.
+func Events(w http.ResponseWriter, r *http.Request) {
+	statetrc.SSEHandler().ServeHTTP(w, r)
+}
+
+// WebSocket streams changes to the entries over a WebSocket.
+func WebSocket(w http.ResponseWriter, r *http.Request) {
+	statetrc.WebSocketHandler().ServeHTTP(w, r)
+}
+
+// Dashboard serves a live dashboard page.
+func Dashboard(w http.ResponseWriter, r *http.Request) {
+	statetrc.DashboardHandler().ServeHTTP(w, r)
+}
+
+// status is the handler of Status. It is created once since it keeps the counts of the
+// previous request to compute the rates.
+var status = statetrc.StatusHandler()
+
+// Status serves a status page summarizing the entries, as described for statetrc.StatusHandler.
+func Status(w http.ResponseWriter, r *http.Request) {
+	status.ServeHTTP(w, r)
+}
+
+// Anomalies serves the entries that are unusually old, as described for statetrc.AnomalyHandler.
+func Anomalies(w http.ResponseWriter, r *http.Request) {
+	statetrc.AnomalyHandler(0).ServeHTTP(w, r)
+}
+
+// withFormat returns a copy of r with the format parameter set to format.
+func withFormat(r *http.Request, format string) *http.Request {
+	r2 := r.Clone(r.Context())
+	q := r2.URL.Query()
+	q.Set("format", format)
+	r2.URL.RawQuery = q.Encode()
+	return r2
+}
@@ -0,0 +1,68 @@
+//go:build !statetrc_off
+
+package httptrc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffwilliams/statetrc"
+)
+
+func get(target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	http.DefaultServeMux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	return w
+}
+
+func TestHandlers(t *testing.T) {
+	statetrc.Enter("/httptrc/test", nil)
+	defer statetrc.Leave("/httptrc/test")
+
+	tests := []struct {
+		target string
+		code   int
+		body   string
+	}{
+		{"/debug/statetrc/", 200, "/httptrc/test"},
+		{"/debug/statetrc/json", 200, `"id": "/httptrc/test"`},
+		{"/debug/statetrc/tree", 200, "test"},
+		{"/debug/statetrc/api?prefix=/httptrc", 200, `"total": 1`},
+		{"/debug/statetrc/history", 200, ""},
+		{"/debug/statetrc/status", 200, "statetrc status"},
+		{"/debug/statetrc/anomalies", 200, ""},
+		{"/debug/statetrc/bogus", 404, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := get(tt.target)
+			if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("got %d %q, want %d containing %q", w.Code, w.Body, tt.code, tt.body)
+			}
+		})
+	}
+}
+
+var rateWindow = regexp.MustCompile(`averaged over the last ([^,]+), since`)
+
+func TestStatusRateWindow(t *testing.T) {
+	get("/debug/statetrc/status")
+	time.Sleep(50 * time.Millisecond)
+	body := get("/debug/statetrc/status").Body.String()
+
+	m := rateWindow.FindStringSubmatch(body)
+	if m == nil {
+		t.Fatalf("no rate window in:\n%s", body)
+	}
+	d, err := time.ParseDuration(m[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d < 50*time.Millisecond {
+		t.Errorf("rates averaged over %v, want the time since the previous request", d)
+	}
+}
@@ -0,0 +1,41 @@
+package statetrc
+
+import "iter"
+
+// All returns an iterator over the currently existing entries, in no particular order.
+// The entries are copied before iteration starts, so the loop body may call methods of the Tracer:
+//
+//	for e := range statetrc.All() {
+//		...
+//	}
+func (t *Tracer) All() iter.Seq[Entry] {
+	return entrySeq(func() []Entry { return t.collect(nil).Entries })
+}
+
+// All calls All on the default Tracer.
+func All() iter.Seq[Entry] {
+	return std.All()
+}
+
+// Sorted returns an iterator over the currently existing entries in the specified Order.
+// As for All, the entries are copied before iteration starts.
+func (t *Tracer) Sorted(order Order) iter.Seq[Entry] {
+	return entrySeq(func() []Entry { return t.List(order) })
+}
+
+// Sorted calls Sorted on the default Tracer.
+func Sorted(order Order) iter.Seq[Entry] {
+	return std.Sorted(order)
+}
+
+// entrySeq returns an iterator over the entries returned by list, which is called each time
+// iteration starts.
+func entrySeq(list func() []Entry) iter.Seq[Entry] {
+	return func(yield func(Entry) bool) {
+		for _, e := range list() {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
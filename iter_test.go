@@ -0,0 +1,58 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	tr := NewTracer()
+	tr.Enter("/b", nil)
+	tr.Enter("/a", nil)
+	seq := tr.All()
+	// The entries are copied when iteration starts, not when All is called.
+	tr.Enter("/c", nil)
+
+	var got []string
+	for e := range seq {
+		// The loop body may call methods of the Tracer.
+		tr.Leave(e.Id)
+		got = append(got, e.Id)
+	}
+	sort.Strings(got)
+	if want := []string{"/a", "/b", "/c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if n := tr.Count(); n != 0 {
+		t.Errorf("%d entries left", n)
+	}
+}
+
+func TestSorted(t *testing.T) {
+	tr := NewTracer()
+	for _, id := range []string{"/b", "/c", "/a"} {
+		tr.Enter(id, nil)
+	}
+	tests := []struct {
+		order Order
+		want  []string
+	}{
+		{ById, []string{"/a", "/b", "/c"}},
+		{BySeq, []string{"/b", "/c", "/a"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for e := range tr.Sorted(tt.order) {
+			got = append(got, e.Id)
+			if len(got) == 2 {
+				break
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want[:2]) {
+			t.Errorf("got %v, want %v", got, tt.want[:2])
+		}
+	}
+}
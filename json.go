@@ -0,0 +1,176 @@
+package statetrc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// jsonEntry is the JSON form of an Entry.
+type jsonEntry struct {
+	Id      string            `json:"id"`
+	Parent  string            `json:"parent,omitempty"`
+	Start   time.Time         `json:"start"`
+	End     *time.Time        `json:"end,omitempty"`
+	Expires *time.Time        `json:"expires,omitempty"`
+	Age     string            `json:"age"`
+	AgeNs   int64             `json:"age_ns"`
+	Count   int               `json:"count"`
+	Seq     uint64            `json:"seq"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Props   json.RawMessage   `json:"props,omitempty"`
+	Stack   string            `json:"stack,omitempty"`
+}
+
+// jsonSnapshot is the JSON form of a Snapshot.
+type jsonSnapshot struct {
+	At      time.Time   `json:"at"`
+	Entries []jsonEntry `json:"entries"`
+}
+
+func toJSONEntry(e Entry, now time.Time) jsonEntry {
+	age := e.Age(now)
+	j := jsonEntry{
+		Id:     e.Id,
+		Parent: e.Parent,
+		Start:  e.Time,
+		Age:    age.String(),
+		AgeNs:  int64(age),
+		Count:  e.Count,
+		Seq:    e.Seq,
+		Labels: e.Labels,
+		Props:  marshalProps(e.Props),
+		Stack:  e.Stack,
+	}
+	if !e.EndTime.IsZero() {
+		j.End = &e.EndTime
+	}
+	if !e.Expires.IsZero() {
+		j.Expires = &e.Expires
+	}
+	return j
+}
+
+func (j jsonEntry) entry() Entry {
+	e := Entry{
+		Id:     j.Id,
+		Parent: j.Parent,
+		Time:   j.Start,
+		Count:  j.Count,
+		Seq:    j.Seq,
+		Labels: j.Labels,
+		Stack:  j.Stack,
+	}
+	if j.End != nil {
+		e.EndTime = *j.End
+	}
+	if j.Expires != nil {
+		e.Expires = *j.Expires
+	}
+	if len(j.Props) > 0 {
+		var props interface{}
+		if json.Unmarshal(j.Props, &props) == nil {
+			e.Props = props
+		}
+	}
+	return e
+}
+
+// marshalProps returns the JSON encoding of props, or of its string form if it
+// cannot be encoded as JSON.
+func marshalProps(props interface{}) json.RawMessage {
+	if props == nil {
+		return nil
+	}
+	b, err := json.Marshal(props)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("%v", props))
+	}
+	return b
+}
+
+// MarshalJSON encodes the Entry as a JSON object with its id, start time in RFC 3339 format,
+// age as both a duration string and nanoseconds, and properties. The age is computed relative
+// to the current time; use Snapshot to compute ages relative to the capture time.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toJSONEntry(e, time.Now()))
+}
+
+// UnmarshalJSON decodes an Entry encoded by MarshalJSON. Props are decoded into generic
+// JSON values.
+func (e *Entry) UnmarshalJSON(b []byte) error {
+	var j jsonEntry
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*e = j.entry()
+	return nil
+}
+
+// MarshalJSON encodes the entries as a JSON array of objects as produced by Entry.MarshalJSON,
+// with all ages computed relative to the same time.
+func (e EntrySlice) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toJSONEntries(e, time.Now()))
+}
+
+func toJSONEntries(l EntrySlice, now time.Time) []jsonEntry {
+	res := make([]jsonEntry, len(l))
+	for i, e := range l {
+		res[i] = toJSONEntry(e, now)
+	}
+	return res
+}
+
+// MarshalJSON encodes the Snapshot as a JSON object with the capture time "at" and the
+// "entries", whose ages are relative to the capture time.
+func (s Snapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonSnapshot{At: s.At, Entries: toJSONEntries(s.Entries, s.At)})
+}
+
+// jsonEvent is the JSON form of an Event.
+type jsonEvent struct {
+	Type  string    `json:"type"`
+	Time  time.Time `json:"time"`
+	Entry jsonEntry `json:"entry"`
+}
+
+// MarshalJSON encodes the Event as a JSON object with its "type" (enter, leave or update),
+// "time" and "entry", whose age is relative to the time of the event.
+func (ev Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonEvent{Type: ev.Type.String(), Time: ev.Time, Entry: toJSONEntry(ev.Entry, ev.Time)})
+}
+
+// UnmarshalJSON decodes an Event encoded by MarshalJSON.
+func (ev *Event) UnmarshalJSON(b []byte) error {
+	var j jsonEvent
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	var typ EventType
+	switch j.Type {
+	case "enter":
+		typ = EnterEvent
+	case "leave":
+		typ = LeaveEvent
+	case "update":
+		typ = UpdateEvent
+	default:
+		return fmt.Errorf("statetrc: unknown event type %q", j.Type)
+	}
+	*ev = Event{Type: typ, Time: j.Time, Entry: j.Entry.entry()}
+	return nil
+}
+
+// UnmarshalJSON decodes a Snapshot encoded by MarshalJSON.
+func (s *Snapshot) UnmarshalJSON(b []byte) error {
+	var j jsonSnapshot
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	s.At = j.At
+	s.Entries = make(EntrySlice, len(j.Entries))
+	for i, je := range j.Entries {
+		s.Entries[i] = je.entry()
+	}
+	return nil
+}
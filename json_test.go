@@ -0,0 +1,88 @@
+package statetrc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testEntries are entries covering the fields the encoders support. Their props are already in
+// the generic form they decode to.
+func testEntries() EntrySlice {
+	start := time.Date(2024, 5, 1, 9, 59, 30, 0, time.UTC)
+	return EntrySlice{
+		{Id: "/conn/1", Time: start, Count: 1, Seq: 1},
+		{
+			Id:      "/conn/1/read",
+			Parent:  "/conn/1",
+			Props:   map[string]interface{}{"addr": "10.0.0.1:5000", "n": float64(3)},
+			Time:    start.Add(time.Second),
+			EndTime: start.Add(2 * time.Second),
+			Expires: start.Add(time.Minute),
+			Labels:  map[string]string{"peer": "a", "proto": "tcp"},
+			Stack:   "goroutine 1 [running]:",
+			Count:   2,
+			Seq:     7,
+		},
+		{Id: "/job", Props: "step \"2\"", Time: start, Count: 1, Seq: 3},
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	want := Snapshot{At: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Entries: testEntries()}
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Snapshot
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip of %s\ngot  %#v\nwant %#v", b, got, want)
+	}
+}
+
+func TestJSONEvent(t *testing.T) {
+	for _, typ := range []EventType{EnterEvent, LeaveEvent, UpdateEvent} {
+		want := Event{Type: typ, Entry: testEntries()[1], Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+		b, err := json.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Event
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("round trip of %s\ngot  %#v\nwant %#v", b, got, want)
+		}
+	}
+
+	var ev Event
+	if err := json.Unmarshal([]byte(`{"type":"bogus"}`), &ev); err == nil {
+		t.Error("decoding an unknown event type succeeded")
+	}
+}
+
+func TestJSONAge(t *testing.T) {
+	s := Snapshot{At: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Entries: testEntries()}
+	b, _ := json.Marshal(s)
+	var j struct {
+		Entries []struct {
+			Age   string `json:"age"`
+			AgeNs int64  `json:"age_ns"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal(b, &j); err != nil {
+		t.Fatal(err)
+	}
+	// The second entry is completed, so its age is its duration.
+	want := []string{"30s", "1s", "30s"}
+	for i, e := range j.Entries {
+		if e.Age != want[i] {
+			t.Errorf("entry %d: age %q, want %q", i, e.Age, want[i])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package statetrc
+
+import "time"
+
+// leakThreshold is a threshold set by SetLeakThreshold.
+type leakThreshold struct {
+	prefix      string
+	age, repeat time.Duration
+	fn          func(e Entry)
+	// reported holds the time each entry was last reported, by sequence number. It is only
+	// used by the leak checking goroutine that was running when the threshold was set.
+	reported map[uint64]time.Time
+}
+
+// SetLeakThreshold arranges for fn to be called once for each entry under the path prefix that
+// becomes older than d, so that stuck states are detected without anyone looking at the
+// entries. Setting a threshold for a prefix replaces the previous one, and a nil fn removes it.
+// See CountPrefix for how prefixes are matched.
+//
+// The entries are checked by a goroutine that runs while any threshold is set, at intervals of
+// a tenth of the smallest threshold, between 10ms and a second. fn is called from that goroutine
+// with the Tracer unlocked, so it may call methods of the Tracer, but it delays further checks.
+func (t *Tracer) SetLeakThreshold(prefix string, d time.Duration, fn func(e Entry)) {
+	t.SetLeakThresholdRepeat(prefix, d, 0, fn)
+}
+
+// SetLeakThreshold calls SetLeakThreshold on the default Tracer.
+func SetLeakThreshold(prefix string, d time.Duration, fn func(e Entry)) {
+	std.SetLeakThreshold(prefix, d, fn)
+}
+
+// SetLeakThresholdRepeat is like SetLeakThreshold, but calls fn again every repeat for as long
+// as the entry exists. If repeat is zero fn is called once for each entry.
+func (t *Tracer) SetLeakThresholdRepeat(prefix string, d, repeat time.Duration, fn func(e Entry)) {
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if fn == nil {
+		delete(t.leaks, prefix)
+		if len(t.leaks) == 0 && t.leakStop != nil {
+			close(t.leakStop)
+			t.leakStop = nil
+		}
+		return
+	}
+
+	if t.leaks == nil {
+		t.leaks = map[string]*leakThreshold{}
+	}
+	t.leaks[prefix] = &leakThreshold{prefix: prefix, age: d, repeat: repeat, fn: fn,
+		reported: map[uint64]time.Time{}}
+	if t.leakStop == nil {
+		t.leakStop = make(chan struct{})
+		go (&Tracer{core: t.core}).checkLeaks(t.leakStop)
+	}
+}
+
+// SetLeakThresholdRepeat calls SetLeakThresholdRepeat on the default Tracer.
+func SetLeakThresholdRepeat(prefix string, d, repeat time.Duration, fn func(e Entry)) {
+	std.SetLeakThresholdRepeat(prefix, d, repeat, fn)
+}
+
+// checkLeaks checks the entries against the leak thresholds until stop is closed. t must not
+// be a namespace, since the thresholds hold full prefixes.
+func (t *Tracer) checkLeaks(stop chan struct{}) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+
+		t.mtx.Lock()
+		select {
+		case <-stop:
+			// The thresholds were removed while the timer fired, and those set since then are
+			// checked by another goroutine.
+			t.mtx.Unlock()
+			return
+		default:
+		}
+		leaks := make([]*leakThreshold, 0, len(t.leaks))
+		interval := time.Second
+		for _, l := range t.leaks {
+			leaks = append(leaks, l)
+			if l.age/10 < interval {
+				interval = l.age / 10
+			}
+		}
+		t.mtx.Unlock()
+
+		for _, l := range leaks {
+			t.checkLeak(l)
+		}
+		if interval < 10*time.Millisecond {
+			interval = 10 * time.Millisecond
+		}
+		timer.Reset(interval)
+	}
+}
+
+// checkLeak calls the function of l for the entries that exceed its threshold.
+func (t *Tracer) checkLeak(l *leakThreshold) {
+	var now time.Time
+	s := t.snapshot(func(e *Entry) bool {
+		if now.IsZero() {
+			now = t.nowLocked()
+		}
+		return hasPathPrefix(e.Id, l.prefix) && e.Age(now) > l.age
+	}, BySeq)
+
+	seen := make(map[uint64]bool, len(s.Entries))
+	for _, e := range s.Entries {
+		seen[e.Seq] = true
+		last, ok := l.reported[e.Seq]
+		if ok && (l.repeat <= 0 || s.At.Sub(last) < l.repeat) {
+			continue
+		}
+		l.reported[e.Seq] = s.At
+		l.fn(e)
+	}
+	for seq := range l.reported {
+		if !seen[seq] {
+			delete(l.reported, seq)
+		}
+	}
+}
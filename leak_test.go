@@ -0,0 +1,70 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetLeakThreshold(t *testing.T) {
+	tr := NewTracer()
+	var mtx sync.Mutex
+	reported := map[string]int{}
+	tr.SetLeakThreshold("/conn", 20*time.Millisecond, func(e Entry) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		reported[e.Id]++
+	})
+	defer tr.SetLeakThreshold("/conn", 0, nil)
+	tr.Enter("/conn/1", nil)
+	tr.Enter("/other", nil)
+	time.Sleep(200 * time.Millisecond)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(reported) != 1 || reported["/conn/1"] != 1 {
+		t.Errorf("reported %v, want /conn/1 once", reported)
+	}
+}
+
+func TestSetLeakThresholdRepeat(t *testing.T) {
+	tr := NewTracer()
+	reports := make(chan Entry, 100)
+	tr.SetLeakThresholdRepeat("", 10*time.Millisecond, 30*time.Millisecond, func(e Entry) { reports <- e })
+	tr.Enter("/a", nil)
+	time.Sleep(200 * time.Millisecond)
+	tr.SetLeakThreshold("", 0, nil)
+	if n := len(reports); n < 3 || n > 8 {
+		t.Errorf("reported %d times in 200ms, want every 30ms", n)
+	}
+}
+
+// TestSetLeakThresholdRestart sets thresholds again right after removing them, which stops the
+// checking goroutine and starts another. Run with -race, since both may briefly be running.
+func TestSetLeakThresholdRestart(t *testing.T) {
+	tr := NewTracer()
+	tr.Enter("/a", nil)
+	var mtx sync.Mutex
+	n := 0
+	fn := func(e Entry) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		n++
+	}
+	for i := 0; i < 50; i++ {
+		tr.SetLeakThreshold("/a", time.Nanosecond, fn)
+		time.Sleep(time.Millisecond)
+		tr.SetLeakThreshold("/a", 0, nil)
+	}
+	tr.SetLeakThreshold("/a", time.Nanosecond, fn)
+	time.Sleep(50 * time.Millisecond)
+	tr.SetLeakThreshold("/a", 0, nil)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if n > 51 {
+		t.Errorf("reported %d times, want at most once per threshold", n)
+	}
+}
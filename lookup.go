@@ -0,0 +1,79 @@
+package statetrc
+
+import "strings"
+
+// Get returns the entry with the specified id, and whether it exists.
+func (t *Tracer) Get(id string) (Entry, bool) {
+	id = t.full(id)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	e, ok := t.entries[id]
+	return t.redactLocked(e), ok
+}
+
+// Get calls Get on the default Tracer.
+func Get(id string) (Entry, bool) {
+	return std.Get(id)
+}
+
+// Exists returns true if an entry with the specified id exists.
+func (t *Tracer) Exists(id string) bool {
+	_, ok := t.Get(id)
+	return ok
+}
+
+// Exists calls Exists on the default Tracer.
+func Exists(id string) bool {
+	return std.Exists(id)
+}
+
+// Count returns the number of existing entries.
+func (t *Tracer) Count() int {
+	if t.prefix != "" {
+		return t.CountPrefix("")
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	return len(t.entries)
+}
+
+// Count calls Count on the default Tracer.
+func Count() int {
+	return std.Count()
+}
+
+// CountPrefix returns the number of existing entries whose ids are under the path prefix.
+// An id is under a prefix if it is equal to it or continues it with a further path element,
+// so "/conn/1" is under "/conn" but "/connection" is not.
+func (t *Tracer) CountPrefix(prefix string) int {
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	n := 0
+	for id := range t.entries {
+		if hasPathPrefix(id, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// CountPrefix calls CountPrefix on the default Tracer.
+func CountPrefix(prefix string) int {
+	return std.CountPrefix(prefix)
+}
+
+// hasPathPrefix returns true if id is equal to prefix or is a path below it.
+func hasPathPrefix(id, prefix string) bool {
+	if !strings.HasPrefix(id, prefix) {
+		return false
+	}
+	if len(id) == len(prefix) || prefix == "" || strings.HasSuffix(prefix, "/") {
+		return true
+	}
+	return id[len(prefix)] == '/'
+}
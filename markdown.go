@@ -0,0 +1,47 @@
+package statetrc
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// MarkdownFormatter writes snapshots as a GitHub-flavored Markdown table with the same columns
+// as TableFormatter, for pasting into issues and chat.
+type MarkdownFormatter struct {
+	// Keys, Order and MaxWidth are as for TableFormatter.
+	Keys     []string
+	Order    Order
+	MaxWidth int
+	// Options controls rendering. If nil, DefaultFormatOptions are used.
+	Options *FormatOptions
+}
+
+// Format implements Formatter.
+func (f MarkdownFormatter) Format(w io.Writer, s Snapshot) error {
+	bw := bufio.NewWriter(w)
+	rows := tableRows(s, f.Keys, f.Order, f.MaxWidth, formatOptions(f.Options))
+	for i, row := range rows {
+		writeMarkdownRow(bw, row)
+		if i == 0 {
+			sep := make([]string, len(row))
+			for j := range sep {
+				sep[j] = "---"
+			}
+			writeMarkdownRow(bw, sep)
+		}
+	}
+	return bw.Flush()
+}
+
+var markdownEscaper = strings.NewReplacer(`|`, `\|`, `\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `<`, `&lt;`)
+
+func writeMarkdownRow(w *bufio.Writer, row []string) {
+	w.WriteString("|")
+	for _, c := range row {
+		w.WriteString(" ")
+		w.WriteString(markdownEscaper.Replace(c))
+		w.WriteString(" |")
+	}
+	w.WriteString("\n")
+}
@@ -0,0 +1,68 @@
+package statetrc
+
+// EntryNode is an Entry along with the entries that were entered as its children using EnterChild.
+type EntryNode struct {
+	Entry
+	Children []*EntryNode
+}
+
+// ListNested returns the currently existing entries arranged by their Parent. Entries without
+// a parent, or whose parent no longer exists, are returned as roots. Roots and the children of
+// each node are ordered in the specified Order.
+func (t *Tracer) ListNested(order Order) []*EntryNode {
+	l := t.List(order)
+
+	nodes := make(map[string]*EntryNode, len(l))
+	for _, e := range l {
+		nodes[e.Id] = &EntryNode{Entry: e}
+	}
+
+	var roots []*EntryNode
+	for _, e := range l {
+		n := nodes[e.Id]
+		if p, ok := nodes[e.Parent]; ok && e.Parent != e.Id {
+			p.Children = append(p.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+
+	// Entries that are part of a cycle of parents are not reachable from any root.
+	// Make them roots so that they are not lost.
+	seen := make(map[*EntryNode]bool, len(l))
+	var visit func(n *EntryNode)
+	visit = func(n *EntryNode) {
+		seen[n] = true
+		for _, c := range n.Children {
+			visit(c)
+		}
+	}
+	for _, n := range roots {
+		visit(n)
+	}
+	for _, e := range l {
+		if n := nodes[e.Id]; !seen[n] {
+			if p, ok := nodes[e.Parent]; ok {
+				p.Children = removeNode(p.Children, n)
+			}
+			roots = append(roots, n)
+			visit(n)
+		}
+	}
+
+	return roots
+}
+
+// ListNested calls ListNested on the default Tracer.
+func ListNested(order Order) []*EntryNode {
+	return std.ListNested(order)
+}
+
+func removeNode(l []*EntryNode, n *EntryNode) []*EntryNode {
+	for i, c := range l {
+		if c == n {
+			return append(l[:i], l[i+1:]...)
+		}
+	}
+	return l
+}
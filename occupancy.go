@@ -0,0 +1,185 @@
+package statetrc
+
+import "time"
+
+// occupancySlot holds the time a prefix had entries during a part of the occupancy window.
+type occupancySlot struct {
+	// n is the number of the part of the window counted by the slot
+	n    int64
+	busy time.Duration
+}
+
+// occupancy tracks the entries under a prefix for Occupancy. since is the time from which the
+// current busy time, if active is not zero, has not been added to the slots yet.
+type occupancy struct {
+	active int
+	since  time.Time
+	slots  [rateSlots]occupancySlot
+}
+
+// SetOccupancyWindow enables tracking of the fraction of a sliding window of the duration window
+// during which there was at least one entry, grouped by the first depth elements of the ids as
+// by Aggregate. This gives a cheap view of the utilization of coarse program states, such as how
+// busy the database was with SetOccupancyWindow(2, time.Minute) and Occupancy("/db/query").
+// Passing a window of zero disables tracking. Changing the window or the depth restarts tracking
+// from the current entries.
+func (t *Tracer) SetOccupancyWindow(depth int, window time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.occupancyDepth, t.occupancyWindow = depth, window
+	t.occupancy = nil
+	if window <= 0 {
+		return
+	}
+	t.occupancy = map[string]*occupancy{}
+	now := t.nowLocked()
+	t.occupancyStart = now
+	for id := range t.entries {
+		t.countOccupancyLocked(id, 1, now)
+	}
+}
+
+// SetOccupancyWindow calls SetOccupancyWindow on the default Tracer.
+func SetOccupancyWindow(depth int, window time.Duration) {
+	std.SetOccupancyWindow(depth, window)
+}
+
+// Occupancy returns the fraction, between 0 and 1, of the window set by SetOccupancyWindow
+// during which the group prefix had at least one entry. Like the rates, it is measured over
+// between 9/10 of the window and the whole window, or since tracking was enabled if that is
+// shorter.
+func (t *Tracer) Occupancy(prefix string) float64 {
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	o, ok := t.occupancy[prefix]
+	if !ok {
+		return 0
+	}
+	return t.occupancyLocked(o, t.nowLocked())
+}
+
+// Occupancy calls Occupancy on the default Tracer.
+func Occupancy(prefix string) float64 {
+	return std.Occupancy(prefix)
+}
+
+// AllOccupancy returns the occupancy of each group that had entries within the window set by
+// SetOccupancyWindow, keyed by prefix.
+func (t *Tracer) AllOccupancy() map[string]float64 {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	now := t.nowLocked()
+	res := map[string]float64{}
+	for key, o := range t.occupancy {
+		f := t.occupancyLocked(o, now)
+		if f == 0 && o.active == 0 {
+			// Drop prefixes that are no longer used so that the map stays small.
+			delete(t.occupancy, key)
+			continue
+		}
+		if t.inScope(key) {
+			res[key] = f
+		}
+	}
+	return res
+}
+
+// AllOccupancy calls AllOccupancy on the default Tracer.
+func AllOccupancy() map[string]float64 {
+	return std.AllOccupancy()
+}
+
+// occupancyPart returns the duration of a part of the occupancy window. t.mtx must be held.
+func (c *core) occupancyPart() time.Duration {
+	part := c.occupancyWindow / rateSlots
+	if part <= 0 {
+		part = 1
+	}
+	return part
+}
+
+// occupancyLocked returns the occupancy tracked by o at now. t.mtx must be held.
+func (c *core) occupancyLocked(o *occupancy, now time.Time) float64 {
+	part := c.occupancyPart()
+	if o.active > 0 {
+		c.addBusyLocked(o, o.since, now)
+		o.since = now
+	}
+	cur := now.UnixNano() / int64(part)
+	var busy time.Duration
+	for _, s := range o.slots {
+		if s.n > cur-rateSlots && s.n <= cur {
+			busy += s.busy
+		}
+	}
+
+	start := time.Unix(0, (cur-rateSlots+1)*int64(part))
+	if start.Before(c.occupancyStart) {
+		start = c.occupancyStart
+	}
+	span := now.Sub(start)
+	if span <= 0 {
+		if o.active > 0 {
+			return 1
+		}
+		return 0
+	}
+	f := float64(busy) / float64(span)
+	if f > 1 {
+		f = 1
+	}
+	return f
+}
+
+// addBusyLocked adds the time from from to to to the slots of o. t.mtx must be held.
+func (c *core) addBusyLocked(o *occupancy, from, to time.Time) {
+	part := int64(c.occupancyPart())
+	first, last := from.UnixNano()/part, to.UnixNano()/part
+	if first < last-rateSlots+1 {
+		// Parts before the window are not kept.
+		first = last - rateSlots + 1
+	}
+	for n := first; n <= last; n++ {
+		lo, hi := time.Unix(0, n*part), time.Unix(0, (n+1)*part)
+		if from.After(lo) {
+			lo = from
+		}
+		if to.Before(hi) {
+			hi = to
+		}
+		if !hi.After(lo) {
+			continue
+		}
+		s := &o.slots[(n%rateSlots+rateSlots)%rateSlots]
+		if s.n != n {
+			*s = occupancySlot{n: n}
+		}
+		s.busy += hi.Sub(lo)
+	}
+}
+
+// countOccupancyLocked adds delta to the number of entries in the group of id for Occupancy.
+// t.mtx must be held.
+func (c *core) countOccupancyLocked(id string, delta int, now time.Time) {
+	if c.occupancy == nil {
+		return
+	}
+	key := idPrefix(id, c.occupancyDepth)
+	o, ok := c.occupancy[key]
+	if !ok {
+		o = &occupancy{}
+		c.occupancy[key] = o
+	}
+	c.setActiveLocked(o, o.active+delta, now)
+}
+
+// setActiveLocked sets the number of entries tracked by o to active at now. t.mtx must be held.
+func (c *core) setActiveLocked(o *occupancy, active int, now time.Time) {
+	if o.active > 0 {
+		c.addBusyLocked(o, o.since, now)
+	}
+	o.active, o.since = active, now
+}
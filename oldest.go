@@ -0,0 +1,65 @@
+package statetrc
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Oldest returns the n longest-running entries, oldest first. It uses a bounded heap rather than
+// sorting all entries, so it is cheap even when there are very many entries.
+func (t *Tracer) Oldest(n int) EntrySlice {
+	if n <= 0 {
+		return EntrySlice{}
+	}
+
+	h := make(newestHeap, 0, n)
+
+	t.mtx.Lock()
+	t.expireLocked()
+	for _, e := range t.entries {
+		if !t.inScope(e.Id) {
+			continue
+		}
+		if len(h) < n {
+			heap.Push(&h, e)
+		} else if older(e, h[0]) {
+			h[0] = e
+			heap.Fix(&h, 0)
+		}
+	}
+	for i := range h {
+		h[i] = t.redactLocked(h[i])
+	}
+	t.mtx.Unlock()
+
+	res := EntrySlice(h)
+	sort.Slice(res, func(i, j int) bool { return older(res[i], res[j]) })
+	return res
+}
+
+// Oldest calls Oldest on the default Tracer.
+func Oldest(n int) EntrySlice {
+	return std.Oldest(n)
+}
+
+// older returns true if a started before b. Sequence numbers break ties between equal times.
+func older(a, b Entry) bool {
+	if a.Time.Equal(b.Time) {
+		return a.Seq < b.Seq
+	}
+	return a.Time.Before(b.Time)
+}
+
+// newestHeap is a heap of entries with the newest at the root.
+type newestHeap []Entry
+
+func (h newestHeap) Len() int            { return len(h) }
+func (h newestHeap) Less(i, j int) bool  { return older(h[j], h[i]) }
+func (h newestHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *newestHeap) Push(x interface{}) { *h = append(*h, x.(Entry)) }
+func (h *newestHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,102 @@
+package statetrc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Option configures an Entry created by EnterWith.
+type Option func(e *Entry)
+
+// WithProps sets the user-defined properties of the Entry.
+func WithProps(props interface{}) Option {
+	return func(e *Entry) {
+		e.Props = props
+	}
+}
+
+// WithLabels sets the structured labels of the Entry.
+func WithLabels(labels map[string]string) Option {
+	return func(e *Entry) {
+		e.Labels = copyLabels(labels)
+	}
+}
+
+// WithParent sets the parent of the Entry, as EnterChild does.
+func WithParent(parentID string) Option {
+	return func(e *Entry) {
+		e.Parent = parentID
+	}
+}
+
+// WithTTL makes the Entry expire after d. Expired entries are removed automatically, which prevents
+// entries from accumulating when Leave is occasionally missed for fire-and-forget states.
+func WithTTL(d time.Duration) Option {
+	return func(e *Entry) {
+		e.ttl = d
+	}
+}
+
+// WithTime sets the start Time of the Entry instead of using the current time. This is useful
+// when the state actually began before the instrumentation was reached, or when importing
+// events from another system.
+func WithTime(start time.Time) Option {
+	return func(e *Entry) {
+		e.Time = start
+	}
+}
+
+// WithContext associates ctx with the Entry, as EnterCtx does, without tying the lifetime of
+// the Entry to ctx.
+func WithContext(ctx context.Context) Option {
+	return func(e *Entry) {
+		e.ctx = ctx
+	}
+}
+
+// WithStack records the stack of the calling goroutine in the Entry.
+func WithStack() Option {
+	return func(e *Entry) {
+		e.Stack = callerStack()
+	}
+}
+
+// EnterWith creates a new Entry like Enter, configured by the passed options.
+func (t *Tracer) EnterWith(id string, opts ...Option) *Region {
+	e := Entry{Id: id}
+	for _, o := range opts {
+		o(&e)
+	}
+	return t.enter(e)
+}
+
+// EnterWith calls EnterWith on the default Tracer.
+func EnterWith(id string, opts ...Option) *Region {
+	return std.EnterWith(id, opts...)
+}
+
+// pkgPrefix is the prefix of the names of functions in this package.
+var pkgPrefix = reflect.TypeOf(Entry{}).PkgPath() + "."
+
+// callerStack formats the stack of the calling goroutine, omitting the frames in this package.
+func callerStack() string {
+	pc := make([]uintptr, 64)
+	n := runtime.Callers(1, pc)
+	frames := runtime.CallersFrames(pc[:n])
+
+	var b strings.Builder
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, pkgPrefix) {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package statetrc
+
+import (
+	"fmt"
+	"time"
+)
+
+// FormatOptions controls how entries are rendered by the String methods and the Formatters.
+type FormatOptions struct {
+	// Round rounds durations to a multiple of Round, for example to time.Millisecond to avoid
+	// nanosecond noise. Zero leaves durations unrounded.
+	Round time.Duration
+	// LargestUnit renders durations using only their largest unit, such as "4m" instead of
+	// "4m12.383912s". It is applied after Round.
+	LargestUnit bool
+	// ShowStart includes the absolute start time of each entry.
+	ShowStart bool
+	// TimeLayout is the layout used for start times. Empty means "2006-01-02 15:04:05.000".
+	TimeLayout string
+	// MaxProps is the maximum number of characters of an entry's rendered props. Longer props
+	// are cut short and end with "...". Zero means no limit.
+	MaxProps int
+}
+
+// DefaultFormatOptions are the options used by the String methods, and by Formatters whose
+// Options are nil. They should only be changed during program initialization.
+var DefaultFormatOptions FormatOptions
+
+// formatOptions returns o, or the default options if o is nil.
+func formatOptions(o *FormatOptions) *FormatOptions {
+	if o == nil {
+		return &DefaultFormatOptions
+	}
+	return o
+}
+
+// duration renders d according to the options.
+func (o *FormatOptions) duration(d time.Duration) string {
+	if o.Round > 0 {
+		d = d.Round(o.Round)
+	}
+	if !o.LargestUnit {
+		return d.String()
+	}
+
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+		{time.Millisecond, "ms"},
+		{time.Microsecond, "µs"},
+	}
+	for _, u := range units {
+		if d >= u.d || -d >= u.d {
+			return fmt.Sprintf("%d%s", d/u.d, u.name)
+		}
+	}
+	return d.String()
+}
+
+// props limits the rendered props s to MaxProps characters.
+func (o *FormatOptions) props(s string) string {
+	return truncate(s, o.MaxProps)
+}
+
+// start renders the start time t according to the options.
+func (o *FormatOptions) start(t time.Time) string {
+	layout := o.TimeLayout
+	if layout == "" {
+		layout = "2006-01-02 15:04:05.000"
+	}
+	return t.Format(layout)
+}
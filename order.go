@@ -0,0 +1,144 @@
+package statetrc
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ByAge is an ordering that may be passed to List to return Entries ordered by age descending,
+	// that is with the oldest first.
+	ByAge Order = func(l []Entry) func(i, j int) bool {
+		return func(i, j int) bool {
+			return older(l[i], l[j])
+		}
+	}
+
+	// ByNewest is an ordering that may be passed to List to return Entries ordered by age ascending,
+	// that is with the newest first.
+	ByNewest Order = Reverse(ByAge)
+)
+
+// Reverse returns the reverse of the ordering o.
+func Reverse(o Order) Order {
+	return func(l []Entry) func(i, j int) bool {
+		less := o(l)
+		return func(i, j int) bool {
+			return less(j, i)
+		}
+	}
+}
+
+// Then returns an ordering that orders entries by primary, and entries that are equal
+// according to primary by secondary.
+func Then(primary, secondary Order) Order {
+	return func(l []Entry) func(i, j int) bool {
+		p, s := primary(l), secondary(l)
+		return func(i, j int) bool {
+			if p(i, j) {
+				return true
+			}
+			if p(j, i) {
+				return false
+			}
+			return s(i, j)
+		}
+	}
+}
+
+// ByPropsField returns an ordering by the named property of the entries, ascending. The property
+// is looked up in the entry's Labels, then as a field of Props if it is a struct or pointer to a
+// struct, or as a key of Props if it is a map with string keys. Numbers, including numeric strings,
+// are compared numerically and other values by their string form. Entries without the property
+// are ordered last.
+func ByPropsField(name string) Order {
+	return func(l []Entry) func(i, j int) bool {
+		return func(i, j int) bool {
+			a, hasA := propsField(l[i], name)
+			b, hasB := propsField(l[j], name)
+			if !hasA || !hasB {
+				return hasA && !hasB
+			}
+			return compareValues(a, b) < 0
+		}
+	}
+}
+
+// propsField returns the named property of e. See ByPropsField.
+func propsField(e Entry, name string) (interface{}, bool) {
+	if v, ok := e.Labels[name]; ok {
+		return v, true
+	}
+
+	v := reflect.ValueOf(e.Props)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		f, ok := v.Type().FieldByName(name)
+		if !ok || !f.IsExported() {
+			return nil, false
+		}
+		return v.FieldByIndex(f.Index).Interface(), true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		f := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !f.IsValid() {
+			return nil, false
+		}
+		return f.Interface(), true
+	}
+	return nil, false
+}
+
+// compareValues compares a and b, numerically if both are numbers or numeric strings and otherwise by
+// their string forms. It returns a negative number if a < b, zero if a == b and a positive
+// number if a > b.
+func compareValues(a, b interface{}) int {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb)
+		}
+	}
+
+	fa, oka := toFloat(a)
+	fb, okb := toFloat(b)
+	if oka && okb {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(x interface{}) (float64, bool) {
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		// Labels are strings, and should still be compared numerically.
+		f, err := strconv.ParseFloat(v.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,81 @@
+package oteltrc
+
+import (
+	"context"
+
+	"github.com/jeffwilliams/statetrc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// quantiles are the quantiles of the durations reported by RegisterMetrics.
+var quantiles = []struct {
+	q    float64
+	name string
+}{{0.5, "0.5"}, {0.95, "0.95"}, {0.99, "0.99"}}
+
+// RegisterMetrics registers asynchronous instruments with mp that report the histograms of the
+// durations of completed entries of t, which must be enabled with statetrc.Tracer.SetStatsDepth
+// and statetrc.Tracer.SetHistogramBuckets. If t is nil the default Tracer is used. For each group
+// of entries, identified by the attribute statetrc.prefix, the instruments are:
+//
+//	statetrc.duration.count      the number of completed entries
+//	statetrc.duration.sum        the total of their durations in seconds
+//	statetrc.duration.quantile   the 50th, 95th and 99th percentiles of their durations in
+//	                             seconds, identified by the attribute quantile
+//
+// If statetrc.Tracer.SetRateWindow enabled tracking of rates, the gauges statetrc.enter.rate and
+// statetrc.leave.rate report the numbers of entries entered and left per second for each of the
+// prefixes it uses.
+//
+// Unregister the returned Registration to stop reporting.
+func RegisterMetrics(t *statetrc.Tracer, mp metric.MeterProvider) (metric.Registration, error) {
+	if t == nil {
+		t = statetrc.Default()
+	}
+	m := mp.Meter(instrumentationName)
+	count, err := m.Int64ObservableCounter("statetrc.duration.count",
+		metric.WithDescription("Number of completed statetrc entries under the prefix."))
+	if err != nil {
+		return nil, err
+	}
+	sum, err := m.Float64ObservableCounter("statetrc.duration.sum", metric.WithUnit("s"),
+		metric.WithDescription("Total duration of completed statetrc entries under the prefix."))
+	if err != nil {
+		return nil, err
+	}
+	quantile, err := m.Float64ObservableGauge("statetrc.duration.quantile", metric.WithUnit("s"),
+		metric.WithDescription("Percentiles of the durations of completed statetrc entries under the prefix."))
+	if err != nil {
+		return nil, err
+	}
+
+	enterRate, err := m.Float64ObservableGauge("statetrc.enter.rate", metric.WithUnit("1/s"),
+		metric.WithDescription("Number of statetrc entries under the prefix entered per second."))
+	if err != nil {
+		return nil, err
+	}
+	leaveRate, err := m.Float64ObservableGauge("statetrc.leave.rate", metric.WithUnit("1/s"),
+		metric.WithDescription("Number of statetrc entries under the prefix left per second."))
+	if err != nil {
+		return nil, err
+	}
+
+	return m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for prefix, h := range t.AllHistograms() {
+			p := attribute.String("statetrc.prefix", prefix)
+			o.ObserveInt64(count, int64(h.Count), metric.WithAttributes(p))
+			o.ObserveFloat64(sum, h.Sum.Seconds(), metric.WithAttributes(p))
+			for _, q := range quantiles {
+				o.ObserveFloat64(quantile, h.Quantile(q.q).Seconds(),
+					metric.WithAttributes(p, attribute.String("quantile", q.name)))
+			}
+		}
+		for _, r := range t.Rates() {
+			p := attribute.String("statetrc.prefix", r.Prefix)
+			o.ObserveFloat64(enterRate, r.Enters, metric.WithAttributes(p))
+			o.ObserveFloat64(leaveRate, r.Leaves, metric.WithAttributes(p))
+		}
+		return nil
+	}, count, sum, quantile, enterRate, leaveRate)
+}
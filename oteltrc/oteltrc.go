@@ -0,0 +1,128 @@
+// Package oteltrc mirrors the entries of a statetrc Tracer as OpenTelemetry spans, so that
+// they appear in existing tracing backends:
+//
+//	b := oteltrc.Start(statetrc.Default(), otel.GetTracerProvider(), "")
+//	defer b.Stop()
+//
+// A span is started when an entry is entered and ended when it is left, with the start and end
+// times of the entry. Entries entered with statetrc.EnterCtx or the statetrc.WithContext option
+// become children of the span in their context, so they join the incoming trace. The labels of
+// an entry become attributes of its span.
+//
+// In RefCount and Multi mode an entry is a single span that ends when its Count drops to zero.
+//
+// RegisterMetrics additionally reports the durations of completed entries and the rates of
+// entering and leaving as metrics.
+package oteltrc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jeffwilliams/statetrc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the OpenTelemetry tracer used for the spans.
+const instrumentationName = "github.com/jeffwilliams/statetrc/oteltrc"
+
+// Bridge creates spans for entries until it is stopped.
+type Bridge struct {
+	tracer trace.Tracer
+	stop   func()
+	done   chan struct{}
+	// spans holds the spans of the active entries, by id
+	spans    map[string]span
+	stopOnce sync.Once
+}
+
+type span struct {
+	seq  uint64
+	span trace.Span
+}
+
+// Start starts creating spans with tp for the entries of t under the path prefix. If t is nil
+// the default Tracer is used.
+//
+// The entries are observed with statetrc.Tracer.Watch, so if the bridge falls far behind the
+// traced program some spans may be missing, or end only when the bridge is stopped.
+func Start(t *statetrc.Tracer, tp trace.TracerProvider, prefix string) *Bridge {
+	if t == nil {
+		t = statetrc.Default()
+	}
+	ch, stop := t.Watch(prefix)
+	b := &Bridge{
+		tracer: tp.Tracer(instrumentationName),
+		stop:   stop,
+		done:   make(chan struct{}),
+		spans:  map[string]span{},
+	}
+	go b.run(ch)
+	return b
+}
+
+// Stop stops creating spans. The spans of entries that are still active are ended.
+func (b *Bridge) Stop() {
+	b.stopOnce.Do(func() {
+		b.stop()
+		<-b.done
+	})
+}
+
+func (b *Bridge) run(ch <-chan statetrc.Event) {
+	defer close(b.done)
+	for ev := range ch {
+		b.handle(ev)
+	}
+	for id, s := range b.spans {
+		s.span.End()
+		delete(b.spans, id)
+	}
+}
+
+func (b *Bridge) handle(ev statetrc.Event) {
+	e := ev.Entry
+	s, ok := b.spans[e.Id]
+	switch ev.Type {
+	case statetrc.EnterEvent:
+		if ok && s.seq == e.Seq {
+			// Another instance of an existing entry.
+			return
+		}
+		if ok {
+			s.span.End(trace.WithTimestamp(ev.Time))
+		}
+		ctx := e.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		_, sp := b.tracer.Start(ctx, e.Id,
+			trace.WithTimestamp(e.Time),
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(attributes(e)...))
+		b.spans[e.Id] = span{seq: e.Seq, span: sp}
+	case statetrc.UpdateEvent:
+		if ok {
+			s.span.SetAttributes(attributes(e)...)
+		}
+	case statetrc.LeaveEvent:
+		if ok && !e.EndTime.IsZero() {
+			s.span.End(trace.WithTimestamp(e.EndTime))
+			delete(b.spans, e.Id)
+		}
+	}
+}
+
+// attributes returns the span attributes of e.
+func attributes(e statetrc.Entry) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(e.Labels)+2)
+	attrs = append(attrs, attribute.String("statetrc.id", e.Id))
+	if e.Parent != "" {
+		attrs = append(attrs, attribute.String("statetrc.parent", e.Parent))
+	}
+	for k, v := range e.Labels {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	return attrs
+}
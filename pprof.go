@@ -0,0 +1,19 @@
+package statetrc
+
+// PprofLabel is the key of the pprof label set by EnterCtx when SetPprofLabels is enabled.
+const PprofLabel = "statetrc"
+
+// SetPprofLabels makes EnterCtx add the full id of the entry to the returned context as the
+// pprof label PprofLabel and apply the labels of the context to the calling goroutine, so that
+// CPU and goroutine profiles can be broken down by state. The function returned by EnterCtx then
+// restores the labels of the original context, so it must be called on the same goroutine.
+// Goroutines started with the returned context inherit the label if they call
+// pprof.SetGoroutineLabels or pprof.Do with it.
+func (t *Tracer) SetPprofLabels(on bool) {
+	t.pprofLabels.Store(on)
+}
+
+// SetPprofLabels calls SetPprofLabels on the default Tracer.
+func SetPprofLabels(on bool) {
+	std.SetPprofLabels(on)
+}
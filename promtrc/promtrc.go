@@ -0,0 +1,139 @@
+// Package promtrc exports metrics about the entries of a statetrc Tracer to Prometheus, so that
+// states that stay active too long can trigger alerts:
+//
+//	prometheus.MustRegister(promtrc.NewCollector(statetrc.Default(), 1))
+//
+// The metrics are:
+//
+//	statetrc_active_entries{prefix}             the number of entries under each prefix
+//	statetrc_oldest_entry_age_seconds{prefix}   the age of the oldest entry under each prefix
+//	statetrc_enters_total                       the number of entries and instances entered
+//	statetrc_leaves_total                       the number of entries and instances left
+//	statetrc_overwrites_total                   the number of entries that replaced an existing entry
+//	statetrc_orphan_leaves_total                the number of leaves of entries that did not exist
+//	statetrc_duration_seconds{prefix}           a histogram of the durations of completed entries
+//	statetrc_enter_rate{prefix}                 the number of entries entered per second
+//	statetrc_leave_rate{prefix}                 the number of entries left per second
+//	statetrc_occupancy_ratio{prefix}            the fraction of time with entries under each prefix
+//	statetrc_anomalous_entries{prefix}          the number of unusually old entries under each prefix
+//	statetrc_budget_used_seconds{prefix}        the time used of each budget set by statetrc.Tracer.SetBudget
+//	statetrc_budget_limit_seconds{prefix}       the limit of each budget
+//
+// The histogram is only exported if it is enabled with statetrc.Tracer.SetStatsDepth and
+// statetrc.Tracer.SetHistogramBuckets, and its prefixes are the groups set by SetStatsDepth.
+// Likewise the rates are only exported if statetrc.Tracer.SetRateWindow enabled them, with the
+// prefixes set by it, and the occupancy only if statetrc.Tracer.SetOccupancyWindow enabled it.
+// The anomalous entries are those reported by statetrc.Tracer.Anomalies with
+// statetrc.DefaultAnomalyFactor, counted by the groups set by SetStatsDepth.
+package promtrc
+
+import (
+	"github.com/jeffwilliams/statetrc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	activeDesc = prometheus.NewDesc("statetrc_active_entries",
+		"Number of active statetrc entries under the prefix.", []string{"prefix"}, nil)
+	oldestDesc = prometheus.NewDesc("statetrc_oldest_entry_age_seconds",
+		"Age of the oldest active statetrc entry under the prefix.", []string{"prefix"}, nil)
+	entersDesc = prometheus.NewDesc("statetrc_enters_total",
+		"Number of statetrc entries and instances entered.", nil, nil)
+	leavesDesc = prometheus.NewDesc("statetrc_leaves_total",
+		"Number of statetrc entries and instances left.", nil, nil)
+	overwritesDesc = prometheus.NewDesc("statetrc_overwrites_total",
+		"Number of statetrc entries that replaced an existing entry.", nil, nil)
+	orphanLeavesDesc = prometheus.NewDesc("statetrc_orphan_leaves_total",
+		"Number of leaves of statetrc entries that did not exist.", nil, nil)
+	enterRateDesc = prometheus.NewDesc("statetrc_enter_rate",
+		"Number of statetrc entries under the prefix entered per second.", []string{"prefix"}, nil)
+	leaveRateDesc = prometheus.NewDesc("statetrc_leave_rate",
+		"Number of statetrc entries under the prefix left per second.", []string{"prefix"}, nil)
+	occupancyDesc = prometheus.NewDesc("statetrc_occupancy_ratio",
+		"Fraction of the recent window during which there were statetrc entries under the prefix.", []string{"prefix"}, nil)
+	anomalousDesc = prometheus.NewDesc("statetrc_anomalous_entries",
+		"Number of statetrc entries under the prefix that are unusually old for it.", []string{"prefix"}, nil)
+	budgetUsedDesc = prometheus.NewDesc("statetrc_budget_used_seconds",
+		"Time used of the statetrc budget for the prefix.", []string{"prefix"}, nil)
+	budgetLimitDesc = prometheus.NewDesc("statetrc_budget_limit_seconds",
+		"Limit of the statetrc budget for the prefix.", []string{"prefix"}, nil)
+	durationDesc = prometheus.NewDesc("statetrc_duration_seconds",
+		"Durations of completed statetrc entries under the prefix.", []string{"prefix"}, nil)
+)
+
+// Collector is a prometheus.Collector for a Tracer.
+type Collector struct {
+	t     *statetrc.Tracer
+	depth int
+}
+
+// NewCollector returns a Collector for t that groups the entries by the first depth elements of
+// their ids, as statetrc.Tracer.Aggregate does. If t is nil the default Tracer is used. Choose
+// depth so that the prefixes do not include unbounded values such as connection numbers, since
+// each prefix becomes a separate time series.
+func NewCollector(t *statetrc.Tracer, depth int) *Collector {
+	if t == nil {
+		t = statetrc.Default()
+	}
+	if depth <= 0 {
+		depth = 1
+	}
+	return &Collector{t: t, depth: depth}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeDesc
+	ch <- oldestDesc
+	ch <- entersDesc
+	ch <- leavesDesc
+	ch <- overwritesDesc
+	ch <- orphanLeavesDesc
+	ch <- durationDesc
+	ch <- enterRateDesc
+	ch <- leaveRateDesc
+	ch <- occupancyDesc
+	ch <- anomalousDesc
+	ch <- budgetUsedDesc
+	ch <- budgetLimitDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.t.Aggregate(c.depth) {
+		ch <- prometheus.MustNewConstMetric(activeDesc, prometheus.GaugeValue, float64(s.Count), s.Prefix)
+		ch <- prometheus.MustNewConstMetric(oldestDesc, prometheus.GaugeValue, s.Oldest.Seconds(), s.Prefix)
+	}
+	d := c.t.Diagnostics()
+	ch <- prometheus.MustNewConstMetric(entersDesc, prometheus.CounterValue, float64(d.Enters))
+	ch <- prometheus.MustNewConstMetric(leavesDesc, prometheus.CounterValue, float64(d.Leaves))
+	ch <- prometheus.MustNewConstMetric(overwritesDesc, prometheus.CounterValue, float64(d.Overwrites))
+	ch <- prometheus.MustNewConstMetric(orphanLeavesDesc, prometheus.CounterValue, float64(d.OrphanLeaves))
+	for prefix, h := range c.t.AllHistograms() {
+		buckets := make(map[float64]uint64, len(h.Bounds))
+		var n uint64
+		for i, b := range h.Bounds {
+			n += h.Counts[i]
+			buckets[b.Seconds()] = n
+		}
+		ch <- prometheus.MustNewConstHistogram(durationDesc, h.Count, h.Sum.Seconds(), buckets, prefix)
+	}
+	for _, r := range c.t.Rates() {
+		ch <- prometheus.MustNewConstMetric(enterRateDesc, prometheus.GaugeValue, r.Enters, r.Prefix)
+		ch <- prometheus.MustNewConstMetric(leaveRateDesc, prometheus.GaugeValue, r.Leaves, r.Prefix)
+	}
+	for prefix, f := range c.t.AllOccupancy() {
+		ch <- prometheus.MustNewConstMetric(occupancyDesc, prometheus.GaugeValue, f, prefix)
+	}
+	anomalous := map[string]int{}
+	for _, a := range c.t.Anomalies(statetrc.DefaultAnomalyFactor) {
+		anomalous[a.Group]++
+	}
+	for prefix, n := range anomalous {
+		ch <- prometheus.MustNewConstMetric(anomalousDesc, prometheus.GaugeValue, float64(n), prefix)
+	}
+	for _, b := range c.t.Budgets() {
+		ch <- prometheus.MustNewConstMetric(budgetUsedDesc, prometheus.GaugeValue, b.Used.Seconds(), b.Prefix)
+		ch <- prometheus.MustNewConstMetric(budgetLimitDesc, prometheus.GaugeValue, b.Limit.Seconds(), b.Prefix)
+	}
+}
@@ -0,0 +1,184 @@
+//go:build !statetrc_off
+
+package promtrc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffwilliams/statetrc"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newTestTracer returns a Tracer whose clock only advances when the returned function is called.
+func newTestTracer() (*statetrc.Tracer, func(d time.Duration)) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	t := statetrc.NewTracer()
+	t.SetClock(func() time.Time { return now })
+	return t, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCollectEntries(t *testing.T) {
+	tr, advance := newTestTracer()
+	tr.Enter("/conn/1", nil)
+	advance(time.Second)
+	tr.Enter("/conn/2", nil)
+	tr.Enter("/job", nil)
+	tr.Enter("/job", nil)
+	tr.Leave("/missing")
+	advance(time.Second)
+
+	want := `
+# HELP statetrc_active_entries Number of active statetrc entries under the prefix.
+# TYPE statetrc_active_entries gauge
+statetrc_active_entries{prefix="/conn"} 2
+statetrc_active_entries{prefix="/job"} 1
+# HELP statetrc_oldest_entry_age_seconds Age of the oldest active statetrc entry under the prefix.
+# TYPE statetrc_oldest_entry_age_seconds gauge
+statetrc_oldest_entry_age_seconds{prefix="/conn"} 2
+statetrc_oldest_entry_age_seconds{prefix="/job"} 1
+# HELP statetrc_enters_total Number of statetrc entries and instances entered.
+# TYPE statetrc_enters_total counter
+statetrc_enters_total 4
+# HELP statetrc_overwrites_total Number of statetrc entries that replaced an existing entry.
+# TYPE statetrc_overwrites_total counter
+statetrc_overwrites_total 1
+# HELP statetrc_orphan_leaves_total Number of leaves of statetrc entries that did not exist.
+# TYPE statetrc_orphan_leaves_total counter
+statetrc_orphan_leaves_total 1
+`
+	err := testutil.CollectAndCompare(NewCollector(tr, 1), strings.NewReader(want),
+		"statetrc_active_entries", "statetrc_oldest_entry_age_seconds", "statetrc_enters_total",
+		"statetrc_overwrites_total", "statetrc_orphan_leaves_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectHistogram(t *testing.T) {
+	tr, advance := newTestTracer()
+	tr.SetStatsDepth(1)
+	tr.SetHistogramBuckets(time.Second, 2*time.Second)
+	for _, d := range []time.Duration{500 * time.Millisecond, 1500 * time.Millisecond, 3 * time.Second} {
+		tr.Enter("/rpc/1", nil)
+		advance(d)
+		tr.Leave("/rpc/1")
+	}
+
+	// The buckets are cumulative, and the entry above the last bound is only in +Inf.
+	want := `
+# HELP statetrc_duration_seconds Durations of completed statetrc entries under the prefix.
+# TYPE statetrc_duration_seconds histogram
+statetrc_duration_seconds_bucket{prefix="/rpc",le="1"} 1
+statetrc_duration_seconds_bucket{prefix="/rpc",le="2"} 2
+statetrc_duration_seconds_bucket{prefix="/rpc",le="+Inf"} 3
+statetrc_duration_seconds_sum{prefix="/rpc"} 5
+statetrc_duration_seconds_count{prefix="/rpc"} 3
+`
+	err := testutil.CollectAndCompare(NewCollector(tr, 1), strings.NewReader(want), "statetrc_duration_seconds")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectDisabledFeatures(t *testing.T) {
+	tr, _ := newTestTracer()
+	tr.Enter("/a", nil)
+	// Only the metrics of the features that are enabled are exported.
+	for _, name := range []string{"statetrc_duration_seconds", "statetrc_enter_rate",
+		"statetrc_occupancy_ratio", "statetrc_budget_used_seconds"} {
+		if n := testutil.CollectAndCount(NewCollector(tr, 1), name); n != 0 {
+			t.Errorf("%d %s metrics, want none", n, name)
+		}
+	}
+}
+
+func TestCollectRates(t *testing.T) {
+	tr, advance := newTestTracer()
+	tr.SetRateWindow(1, 10*time.Second)
+	tr.Enter("/a/1", nil)
+	tr.Enter("/a/2", nil)
+	tr.LeavePrefix("/a")
+	advance(2 * time.Second)
+
+	want := `
+# HELP statetrc_enter_rate Number of statetrc entries under the prefix entered per second.
+# TYPE statetrc_enter_rate gauge
+statetrc_enter_rate{prefix="/a"} 1
+# HELP statetrc_leave_rate Number of statetrc entries under the prefix left per second.
+# TYPE statetrc_leave_rate gauge
+statetrc_leave_rate{prefix="/a"} 1
+# HELP statetrc_leaves_total Number of statetrc entries and instances left.
+# TYPE statetrc_leaves_total counter
+statetrc_leaves_total 2
+`
+	err := testutil.CollectAndCompare(NewCollector(tr, 1), strings.NewReader(want),
+		"statetrc_enter_rate", "statetrc_leave_rate", "statetrc_leaves_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectOccupancy(t *testing.T) {
+	tr, advance := newTestTracer()
+	tr.SetOccupancyWindow(1, 10*time.Second)
+	tr.Enter("/a", nil)
+	advance(time.Second)
+	tr.Leave("/a")
+	advance(time.Second)
+
+	want := `
+# HELP statetrc_occupancy_ratio Fraction of the recent window during which there were statetrc entries under the prefix.
+# TYPE statetrc_occupancy_ratio gauge
+statetrc_occupancy_ratio{prefix="/a"} 0.5
+`
+	err := testutil.CollectAndCompare(NewCollector(tr, 1), strings.NewReader(want), "statetrc_occupancy_ratio")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectAnomalies(t *testing.T) {
+	tr, advance := newTestTracer()
+	tr.SetStatsDepth(1)
+	for i := 0; i < 10; i++ {
+		tr.Enter("/job/1", nil)
+		advance(time.Second)
+		tr.Leave("/job/1")
+	}
+	tr.Enter("/job/2", nil)
+	tr.Enter("/job/3", nil)
+	advance(time.Minute)
+
+	want := `
+# HELP statetrc_anomalous_entries Number of statetrc entries under the prefix that are unusually old for it.
+# TYPE statetrc_anomalous_entries gauge
+statetrc_anomalous_entries{prefix="/job"} 2
+`
+	err := testutil.CollectAndCompare(NewCollector(tr, 1), strings.NewReader(want), "statetrc_anomalous_entries")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectBudgets(t *testing.T) {
+	tr, advance := newTestTracer()
+	tr.SetBudget("/startup", 10*time.Second, nil)
+	tr.Enter("/startup/db", nil)
+	advance(3 * time.Second)
+
+	want := `
+# HELP statetrc_budget_limit_seconds Limit of the statetrc budget for the prefix.
+# TYPE statetrc_budget_limit_seconds gauge
+statetrc_budget_limit_seconds{prefix="/startup"} 10
+# HELP statetrc_budget_used_seconds Time used of the statetrc budget for the prefix.
+# TYPE statetrc_budget_used_seconds gauge
+statetrc_budget_used_seconds{prefix="/startup"} 3
+`
+	err := testutil.CollectAndCompare(NewCollector(tr, 1), strings.NewReader(want),
+		"statetrc_budget_limit_seconds", "statetrc_budget_used_seconds")
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,296 @@
+package statetrc
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// The protocol buffer encoding of snapshots is described by statetrc.proto. It is implemented
+// directly rather than with generated code so that the package does not depend on the protobuf
+// runtime.
+
+// Field numbers from statetrc.proto.
+const (
+	protoEntryId      = 1
+	protoEntryParent  = 2
+	protoEntryStart   = 3
+	protoEntryEnd     = 4
+	protoEntryExpires = 5
+	protoEntryCount   = 6
+	protoEntrySeq     = 7
+	protoEntryLabels  = 8
+	protoEntryProps   = 9
+	protoEntryStack   = 10
+
+	protoSnapshotAt      = 1
+	protoSnapshotEntries = 2
+
+	protoEventType  = 1
+	protoEventTime  = 2
+	protoEventEntry = 3
+
+	protoMapKey   = 1
+	protoMapValue = 2
+)
+
+// Wire types.
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+var errProtoTruncated = errors.New("statetrc: truncated protobuf data")
+
+// MarshalProto encodes the Snapshot as a Snapshot message of statetrc.proto. Props are
+// stored in their JSON encoding.
+func (s Snapshot) MarshalProto() ([]byte, error) {
+	var b []byte
+	b = appendProtoTime(b, protoSnapshotAt, s.At)
+	var eb []byte
+	for _, e := range s.Entries {
+		eb = appendProtoEntry(eb[:0], e)
+		b = appendProtoBytes(b, protoSnapshotEntries, eb)
+	}
+	return b, nil
+}
+
+// UnmarshalProto decodes a Snapshot message of statetrc.proto. Unknown fields are ignored.
+// As for UnmarshalJSON, Props are decoded into generic JSON values.
+func (s *Snapshot) UnmarshalProto(b []byte) error {
+	var res Snapshot
+	err := parseProto(b, func(num, typ int, v uint64, data []byte) error {
+		switch {
+		case num == protoSnapshotAt && typ == protoVarint:
+			res.At = protoTime(v)
+		case num == protoSnapshotEntries && typ == protoBytes:
+			e, err := parseProtoEntry(data)
+			if err != nil {
+				return err
+			}
+			res.Entries = append(res.Entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if res.Entries == nil {
+		res.Entries = EntrySlice{}
+	}
+	*s = res
+	return nil
+}
+
+// MarshalProto encodes the Event as an Event message of statetrc.proto.
+func (ev Event) MarshalProto() ([]byte, error) {
+	var b []byte
+	b = appendProtoVarint(b, protoEventType, uint64(ev.Type))
+	b = appendProtoTime(b, protoEventTime, ev.Time)
+	b = appendProtoBytes(b, protoEventEntry, appendProtoEntry(nil, ev.Entry))
+	return b, nil
+}
+
+// UnmarshalProto decodes an Event message of statetrc.proto. Unknown fields are ignored.
+func (ev *Event) UnmarshalProto(b []byte) error {
+	var res Event
+	err := parseProto(b, func(num, typ int, v uint64, data []byte) error {
+		switch {
+		case num == protoEventType && typ == protoVarint:
+			res.Type = EventType(v)
+		case num == protoEventTime && typ == protoVarint:
+			res.Time = protoTime(v)
+		case num == protoEventEntry && typ == protoBytes:
+			e, err := parseProtoEntry(data)
+			if err != nil {
+				return err
+			}
+			res.Entry = e
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*ev = res
+	return nil
+}
+
+func appendProtoEntry(b []byte, e Entry) []byte {
+	b = appendProtoString(b, protoEntryId, e.Id)
+	b = appendProtoString(b, protoEntryParent, e.Parent)
+	b = appendProtoTime(b, protoEntryStart, e.Time)
+	b = appendProtoTime(b, protoEntryEnd, e.EndTime)
+	b = appendProtoTime(b, protoEntryExpires, e.Expires)
+	b = appendProtoVarint(b, protoEntryCount, uint64(int64(e.Count)))
+	b = appendProtoVarint(b, protoEntrySeq, e.Seq)
+
+	keys := make([]string, 0, len(e.Labels))
+	for k := range e.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var lb []byte
+	for _, k := range keys {
+		lb = appendProtoString(lb[:0], protoMapKey, k)
+		lb = appendProtoString(lb, protoMapValue, e.Labels[k])
+		b = appendProtoBytes(b, protoEntryLabels, lb)
+	}
+
+	b = appendProtoBytes(b, protoEntryProps, marshalProps(e.Props))
+	b = appendProtoString(b, protoEntryStack, e.Stack)
+	return b
+}
+
+func parseProtoEntry(b []byte) (Entry, error) {
+	var e Entry
+	err := parseProto(b, func(num, typ int, v uint64, data []byte) error {
+		if typ == protoBytes {
+			switch num {
+			case protoEntryId:
+				e.Id = string(data)
+			case protoEntryParent:
+				e.Parent = string(data)
+			case protoEntryLabels:
+				var k, val string
+				err := parseProto(data, func(num, typ int, _ uint64, data []byte) error {
+					if typ == protoBytes && num == protoMapKey {
+						k = string(data)
+					} else if typ == protoBytes && num == protoMapValue {
+						val = string(data)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				if e.Labels == nil {
+					e.Labels = make(map[string]string)
+				}
+				e.Labels[k] = val
+			case protoEntryProps:
+				var props interface{}
+				if json.Unmarshal(data, &props) == nil {
+					e.Props = props
+				}
+			case protoEntryStack:
+				e.Stack = string(data)
+			}
+			return nil
+		}
+		if typ == protoVarint {
+			switch num {
+			case protoEntryStart:
+				e.Time = protoTime(v)
+			case protoEntryEnd:
+				e.EndTime = protoTime(v)
+			case protoEntryExpires:
+				e.Expires = protoTime(v)
+			case protoEntryCount:
+				e.Count = int(int64(v))
+			case protoEntrySeq:
+				e.Seq = v
+			}
+		}
+		return nil
+	})
+	return e, err
+}
+
+// parseProto calls fn for each field in the message b. For varint fields v holds the value,
+// and for length-delimited fields data holds the contents. Fixed-size fields are skipped.
+func parseProto(b []byte, fn func(num, typ int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errProtoTruncated
+		}
+		b = b[n:]
+		num, typ := int(key>>3), int(key&7)
+
+		var v uint64
+		var data []byte
+		switch typ {
+		case protoVarint:
+			v, n = binary.Uvarint(b)
+			if n <= 0 {
+				return errProtoTruncated
+			}
+			b = b[n:]
+		case protoBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return errProtoTruncated
+			}
+			data = b[n : n+int(l)]
+			b = b[n+int(l):]
+		case protoFixed64, protoFixed32:
+			size := 8
+			if typ == protoFixed32 {
+				size = 4
+			}
+			if len(b) < size {
+				return errProtoTruncated
+			}
+			b = b[size:]
+			continue
+		default:
+			return fmt.Errorf("statetrc: unsupported protobuf wire type %d", typ)
+		}
+
+		if err := fn(num, typ, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendProtoKey(b []byte, num, typ int) []byte {
+	return binary.AppendUvarint(b, uint64(num)<<3|uint64(typ))
+}
+
+func appendProtoVarint(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendProtoKey(b, num, protoVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+func appendProtoBytes(b []byte, num int, data []byte) []byte {
+	if len(data) == 0 {
+		return b
+	}
+	b = appendProtoKey(b, num, protoBytes)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendProtoString(b []byte, num int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = appendProtoKey(b, num, protoBytes)
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// appendProtoTime appends t as nanoseconds since the Unix epoch, omitting the zero time.
+func appendProtoTime(b []byte, num int, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	return appendProtoVarint(b, num, uint64(t.UnixNano()))
+}
+
+func protoTime(v uint64) time.Time {
+	if v == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(v))
+}
@@ -0,0 +1,67 @@
+package statetrc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// inUTC returns s with its times in UTC, since the protobuf encoding does not keep locations.
+func inUTC(s Snapshot) Snapshot {
+	s.At = s.At.UTC()
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		e.Time = e.Time.UTC()
+		e.EndTime, e.Expires = e.EndTime.UTC(), e.Expires.UTC()
+	}
+	return s
+}
+
+func TestProtoRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		s    Snapshot
+	}{
+		{"empty", Snapshot{At: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Entries: EntrySlice{}}},
+		{"entries", Snapshot{At: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Entries: testEntries()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.s.MarshalProto()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got Snapshot
+			if err := got.UnmarshalProto(b); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(inUTC(got), tt.s) {
+				t.Errorf("got  %#v\nwant %#v", got, tt.s)
+			}
+		})
+	}
+}
+
+func TestProtoEvent(t *testing.T) {
+	want := Event{Type: LeaveEvent, Entry: testEntries()[1], Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	b, err := want.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Event
+	if err := got.UnmarshalProto(b); err != nil {
+		t.Fatal(err)
+	}
+	got.Time, got.Entry = got.Time.UTC(), inUTC(Snapshot{Entries: EntrySlice{got.Entry}}).Entries[0]
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %#v\nwant %#v", got, want)
+	}
+}
+
+func TestProtoTruncated(t *testing.T) {
+	b, _ := Snapshot{At: time.Now(), Entries: testEntries()}.MarshalProto()
+	var s Snapshot
+	if err := s.UnmarshalProto(b[:len(b)-3]); err == nil {
+		t.Error("decoding truncated data succeeded")
+	}
+}
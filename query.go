@@ -0,0 +1,194 @@
+package statetrc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// query is a parsed Query string.
+type query struct {
+	prefix         string
+	glob           string
+	re             *regexp.Regexp
+	labels         map[string]string
+	minAge, maxAge time.Duration
+	order          Order
+	offset, limit  int
+}
+
+// Query returns the currently existing entries selected by the query string q, which is made
+// of space-separated terms. All terms must match for an entry to be selected. The terms are:
+//
+//	prefix=P       ids under the path prefix P
+//	glob=G         ids matching the glob pattern G, as for ListGlob
+//	match=RE       ids matching the regular expression RE
+//	label.K=V      entries whose label K has the value V
+//	age>D, age<D   entries older or younger than the duration D, such as 30s
+//	order=O        order the entries by O, which is one of id, age, newest or seq, or
+//	               prop:NAME to order by a property as ByPropsField does. A leading '-'
+//	               reverses the order.
+//	offset=N       skip the first N entries
+//	limit=N        return at most N entries
+//
+// For example "prefix=/conn age>30s order=age limit=20" returns the 20 oldest entries under
+// /conn that are older than 30 seconds. This lets debug endpoints and tools slice the data
+// without writing Go code.
+func (t *Tracer) Query(q string) (EntrySlice, error) {
+	pq, err := parseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	return t.runQuery(pq).Entries, nil
+}
+
+// Query calls Query on the default Tracer.
+func Query(q string) (EntrySlice, error) {
+	return std.Query(q)
+}
+
+// QuerySnapshot is like Query, but returns a Snapshot of the matching entries, which also holds
+// the time they were copied, so that their ages can be computed as of then.
+func (t *Tracer) QuerySnapshot(q string) (Snapshot, error) {
+	pq, err := parseQuery(q)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return t.runQuery(pq), nil
+}
+
+// QuerySnapshot calls QuerySnapshot on the default Tracer.
+func QuerySnapshot(q string) (Snapshot, error) {
+	return std.QuerySnapshot(q)
+}
+
+func parseQuery(q string) (*query, error) {
+	pq := &query{}
+	for _, term := range strings.Fields(q) {
+		if err := pq.parseTerm(term); err != nil {
+			return nil, fmt.Errorf("statetrc: bad query term %q: %w", term, err)
+		}
+	}
+	return pq, nil
+}
+
+func (q *query) parseTerm(term string) error {
+	if rest, ok := strings.CutPrefix(term, "age"); ok && len(rest) > 1 && (rest[0] == '>' || rest[0] == '<') {
+		d, err := time.ParseDuration(strings.TrimPrefix(rest[1:], "="))
+		if err != nil {
+			return err
+		}
+		if rest[0] == '>' {
+			q.minAge = d
+		} else {
+			q.maxAge = d
+		}
+		return nil
+	}
+
+	key, val, ok := strings.Cut(term, "=")
+	if !ok {
+		return fmt.Errorf("expected key=value")
+	}
+
+	var err error
+	switch {
+	case key == "prefix":
+		q.prefix = val
+	case key == "glob":
+		q.glob = val
+		_, err = compileGlob(val)
+	case key == "match":
+		q.re, err = regexp.Compile(val)
+	case strings.HasPrefix(key, "label."):
+		if q.labels == nil {
+			q.labels = map[string]string{}
+		}
+		q.labels[key[len("label."):]] = val
+	case key == "order":
+		q.order, err = parseOrder(val)
+	case key == "offset":
+		q.offset, err = strconv.Atoi(val)
+	case key == "limit":
+		q.limit, err = strconv.Atoi(val)
+	default:
+		err = fmt.Errorf("unknown key %q", key)
+	}
+	return err
+}
+
+// parseOrder returns the Order named by s. See Query for the names.
+func parseOrder(s string) (Order, error) {
+	reverse := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	var o Order
+	switch {
+	case s == "id":
+		o = ById
+	case s == "age":
+		o = ByAge
+	case s == "newest":
+		o = ByNewest
+	case s == "seq":
+		o = BySeq
+	case strings.HasPrefix(s, "prop:"):
+		o = ByPropsField(s[len("prop:"):])
+	default:
+		return nil, fmt.Errorf("unknown order %q", s)
+	}
+
+	if reverse {
+		o = Reverse(o)
+	}
+	return o, nil
+}
+
+// runQuery returns a Snapshot of the entries selected by q.
+func (t *Tracer) runQuery(q *query) Snapshot {
+	var now time.Time
+	keep := t.queryFilter(q, func() time.Time {
+		if now.IsZero() {
+			now = t.nowLocked()
+		}
+		return now
+	})
+	s := t.snapshot(keep, q.order)
+
+	s.Entries = page(s.Entries, q.offset, q.limit)
+	return s
+}
+
+// queryFilter returns a function reporting whether an entry is selected by q, ignoring its
+// order and paging. now returns the time ages are relative to.
+func (t *Tracer) queryFilter(q *query, now func() time.Time) func(e *Entry) bool {
+	prefix := t.full(q.prefix)
+	var g glob
+	if q.glob != "" {
+		// The pattern was checked when it was parsed.
+		g, _ = compileGlob(t.full(q.glob))
+	}
+
+	return func(e *Entry) bool {
+		switch {
+		case q.prefix != "" && !hasPathPrefix(e.Id, prefix):
+			return false
+		case g != nil && !g.match(e.Id):
+			return false
+		case q.re != nil && !q.re.MatchString(e.Id):
+			return false
+		case q.minAge > 0 && e.Age(now()) <= q.minAge:
+			return false
+		case q.maxAge > 0 && e.Age(now()) >= q.maxAge:
+			return false
+		}
+		for k, v := range q.labels {
+			if l, ok := e.Labels[k]; !ok || l != v {
+				return false
+			}
+		}
+		return true
+	}
+}
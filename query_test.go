@@ -0,0 +1,87 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newQueryTracer returns a Tracer with entries of different ages and labels.
+func newQueryTracer() *Tracer {
+	tr, clock := newTestTracer()
+	tr.EnterL("/conn/1", map[string]string{"peer": "a"})
+	clock.Add(time.Minute)
+	tr.EnterL("/conn/2", map[string]string{"peer": "b"})
+	clock.Add(time.Minute)
+	tr.Enter("/conn/3/read", map[string]interface{}{"n": 2})
+	tr.Enter("/job", map[string]interface{}{"n": 1})
+	clock.Add(time.Second)
+	return tr
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want []string
+	}{
+		{"", []string{"/conn/1", "/conn/2", "/conn/3/read", "/job"}},
+		{"prefix=/conn", []string{"/conn/1", "/conn/2", "/conn/3/read"}},
+		{"prefix=/con", nil},
+		{"glob=/conn/*", []string{"/conn/1", "/conn/2"}},
+		{"match=[23]", []string{"/conn/2", "/conn/3/read"}},
+		{"label.peer=b", []string{"/conn/2"}},
+		{"age>30s", []string{"/conn/1", "/conn/2"}},
+		{"age<30s", []string{"/conn/3/read", "/job"}},
+		{"age>30s age<90s", []string{"/conn/2"}},
+		{"order=age", []string{"/conn/1", "/conn/2", "/conn/3/read", "/job"}},
+		{"order=-id", []string{"/job", "/conn/3/read", "/conn/2", "/conn/1"}},
+		{"prefix=/conn order=-seq offset=1 limit=1", []string{"/conn/2"}},
+		{"age<30s order=prop:n", []string{"/job", "/conn/3/read"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			tr := newQueryTracer()
+			l, err := tr.Query(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ids(l)
+			if tt.want == nil {
+				tt.want = []string{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	for _, q := range []string{
+		"prefix",
+		"bogus=1",
+		"match=(",
+		"glob=[",
+		"order=size",
+		"limit=many",
+		"offset=",
+		"age>forever",
+	} {
+		if _, err := parseQuery(q); err == nil {
+			t.Errorf("parseQuery(%q) succeeded", q)
+		}
+	}
+}
+
+func TestQueryNamespace(t *testing.T) {
+	tr := newQueryTracer()
+	l, err := tr.Namespace("/conn").Query("prefix=/3 glob=/*/read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(l), []string{"/conn/3/read"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package statetrc
+
+import (
+	"sort"
+	"time"
+)
+
+// rateSlots is the number of parts the window set by SetRateWindow is divided into. Calls are
+// counted in the part they happened in, so the rates are averages over between 9/10 of the
+// window and the whole window.
+const rateSlots = 10
+
+// Rate holds the rates at which the entries under a prefix are entered and left.
+type Rate struct {
+	// Prefix of the ids of the entries
+	Prefix string
+	// Enters and Leaves are the average numbers of entries and instances entered and left per
+	// second, counted as for DiagnosticCounters.
+	Enters, Leaves float64
+}
+
+type rateSlot struct {
+	// n is the number of the part of the window counted by the slot
+	n              int64
+	enters, leaves uint64
+}
+
+// rateCounter counts the calls for a prefix in the parts of the window, which are used in turn.
+type rateCounter [rateSlots]rateSlot
+
+// SetRateWindow enables tracking of the rates of Enter and Leave over a sliding window of the
+// duration window, grouped by the first depth elements of the ids as by Aggregate. A drop in the
+// rate of Leave relative to that of Enter is an early sign of a wedged subsystem. Passing a
+// window of zero disables tracking. Changing the window or the depth discards the counts.
+func (t *Tracer) SetRateWindow(depth int, window time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.rateDepth, t.rateWindow = depth, window
+	t.rateStart = t.nowLocked()
+	t.rates = nil
+	if window > 0 {
+		t.rates = map[string]*rateCounter{}
+	}
+}
+
+// SetRateWindow calls SetRateWindow on the default Tracer.
+func SetRateWindow(depth int, window time.Duration) {
+	std.SetRateWindow(depth, window)
+}
+
+// Rates returns the rates for each prefix that had entries entered or left within the window
+// set by SetRateWindow, ordered by Prefix. Until a window has passed since SetRateWindow was
+// called the rates are averages over the time since then.
+func (t *Tracer) Rates() []Rate {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	res, _ := t.ratesLocked()
+	return res
+}
+
+// Rates calls Rates on the default Tracer.
+func Rates() []Rate {
+	return std.Rates()
+}
+
+// totalRates returns the sums of the rates of all prefixes and the time they are averaged
+// over, which is zero if tracking of rates is disabled.
+func (t *Tracer) totalRates() (enters, leaves float64, span time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	res, span := t.ratesLocked()
+	for _, r := range res {
+		enters += r.Enters
+		leaves += r.Leaves
+	}
+	return enters, leaves, span
+}
+
+// ratesLocked implements Rates, and also returns the time the counts were made in, which is
+// zero if tracking of rates is disabled. t.mtx must be held.
+func (t *Tracer) ratesLocked() ([]Rate, time.Duration) {
+	if t.rates == nil {
+		return nil, 0
+	}
+	now := t.nowLocked()
+	part := t.ratePart()
+	cur := now.UnixNano() / int64(part)
+
+	// The counts are those of the parts of the window from the one starting at start, or of
+	// the time since tracking started. This is at least one part, so that the first calls
+	// are not reported as bursts.
+	start := time.Unix(0, (cur-rateSlots+1)*int64(part))
+	if start.Before(t.rateStart) {
+		start = t.rateStart
+	}
+	span := now.Sub(start)
+	if span < part {
+		span = part
+	}
+	secs := span.Seconds()
+
+	var res []Rate
+	for key, c := range t.rates {
+		var enters, leaves uint64
+		for _, s := range c {
+			if s.n > cur-rateSlots && s.n <= cur {
+				enters += s.enters
+				leaves += s.leaves
+			}
+		}
+		if enters == 0 && leaves == 0 {
+			// Drop prefixes that are no longer used so that the map stays small.
+			delete(t.rates, key)
+			continue
+		}
+		if t.inScope(key) {
+			res = append(res, Rate{Prefix: key, Enters: float64(enters) / secs, Leaves: float64(leaves) / secs})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Prefix < res[j].Prefix })
+	return res, span
+}
+
+// ratePart returns the duration of a part of the rate window. t.mtx must be held.
+func (c *core) ratePart() time.Duration {
+	part := c.rateWindow / rateSlots
+	if part <= 0 {
+		part = 1
+	}
+	return part
+}
+
+// rateSlotLocked returns the number of the current part of the rate window. t.mtx must be held.
+func (c *core) rateSlotLocked() int64 {
+	return c.nowLocked().UnixNano() / int64(c.ratePart())
+}
+
+// countRateLocked counts an enter, or a leave if enter is false, of the entry id for Rates.
+// t.mtx must be held.
+func (c *core) countRateLocked(id string, enter bool) {
+	if c.rates == nil {
+		return
+	}
+	key := idPrefix(id, c.rateDepth)
+	r, ok := c.rates[key]
+	if !ok {
+		r = &rateCounter{}
+		c.rates[key] = r
+	}
+	n := c.rateSlotLocked()
+	s := &r[(n%rateSlots+rateSlots)%rateSlots]
+	if s.n != n {
+		*s = rateSlot{n: n}
+	}
+	if enter {
+		s.enters++
+	} else {
+		s.leaves++
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRates(t *testing.T) {
+	tests := []struct {
+		name string
+		// after is the time after SetRateWindow at which the calls are made, and at is the time
+		// after them at which the rates are read
+		after, at time.Duration
+		want      []Rate
+	}{
+		{"first part", 0, 0, []Rate{{"/a", 2, 1}, {"/b", 1, 0}}},
+		{"first window", 0, 4 * time.Second, []Rate{{"/a", 0.5, 0.25}, {"/b", 0.25, 0}}},
+		// Once a window has passed the counts cover between 9 and 10 parts of it.
+		{"later window", time.Minute, 9 * time.Second, []Rate{{"/a", 2.0 / 9, 1.0 / 9}, {"/b", 1.0 / 9, 0}}},
+		{"expired", 0, 20 * time.Second, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, clock := newTestTracer()
+			tr.SetRateWindow(1, 10*time.Second)
+			clock.Add(tt.after)
+			tr.Enter("/a/1", nil)
+			tr.Enter("/a/2", nil)
+			tr.Enter("/b", nil)
+			tr.Leave("/a/1")
+			clock.Add(tt.at)
+			if got := tr.Rates(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRatesLeavePrefix(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.SetRateWindow(1, 10*time.Second)
+	tr.SetMode(RefCount)
+	tr.Enter("/a/1", nil)
+	tr.Enter("/a/1", nil)
+	tr.Enter("/a/2", nil)
+	tr.LeavePrefix("/a")
+	clock.Add(time.Second)
+	if got, want := tr.Rates(), []Rate{{"/a", 3, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
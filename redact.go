@@ -0,0 +1,31 @@
+package statetrc
+
+// Redactor returns the props to show for the entry with the given id in place of props. It
+// can be used to remove credentials or personal data from props before they are formatted or
+// exported.
+type Redactor func(id string, props interface{}) interface{}
+
+// SetRedactor sets a Redactor that is applied to the props of every entry returned by the
+// Tracer, by List and the functions built on it, such as Snapshot, Query and the formatters and
+// exporters, as well as by Get and Range, to entries in the history and to Events sent to
+// watchers. Since GetT returns props of its type only, it reports props that r replaced with a
+// value of another type as missing. r is called with the Tracer locked, so it must not call
+// methods of the Tracer. Passing nil removes the Redactor.
+func (t *Tracer) SetRedactor(r Redactor) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.redact = r
+}
+
+// SetRedactor calls SetRedactor on the default Tracer.
+func SetRedactor(r Redactor) {
+	std.SetRedactor(r)
+}
+
+// redactLocked returns e with the Redactor applied to its props. t.mtx must be held.
+func (c *core) redactLocked(e Entry) Entry {
+	if c.redact != nil && e.Props != nil {
+		e.Props = c.redact(e.Id, e.Props)
+	}
+	return e
+}
@@ -0,0 +1,59 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	tr := NewTracer()
+	tr.SetHistorySize(10)
+	tr.SetRedactor(func(id string, props interface{}) interface{} {
+		if strings.HasPrefix(id, "/login") {
+			return "redacted"
+		}
+		return props
+	})
+	tr.Enter("/login/1", "password")
+	tr.Enter("/other", "visible")
+
+	tests := []struct {
+		name string
+		get  func() interface{}
+		want interface{}
+	}{
+		{"List", func() interface{} { return tr.List(ById)[0].Props }, "redacted"},
+		{"Get", func() interface{} { e, _ := tr.Get("/login/1"); return e.Props }, "redacted"},
+		{"Range", func() interface{} {
+			var props interface{}
+			tr.Range(func(e Entry) bool {
+				if e.Id == "/login/1" {
+					props = e.Props
+				}
+				return true
+			})
+			return props
+		}, "redacted"},
+		{"other", func() interface{} { e, _ := tr.Get("/other"); return e.Props }, "visible"},
+		{"History", func() interface{} { tr.Leave("/login/1"); return tr.completed()[0].Props }, "redacted"},
+	}
+	for _, tt := range tests {
+		if got := tt.get(); got != tt.want {
+			t.Errorf("%s: props %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRedactorTyped(t *testing.T) {
+	tr := NewTracer()
+	tr.SetRedactor(func(id string, props interface{}) interface{} { return "redacted" })
+	Typed[int](tr).Enter("/a", 1)
+	if v, ok := Typed[int](tr).Get("/a"); ok {
+		t.Errorf("Get returned %v, want the redacted props reported as missing", v)
+	}
+	if v, ok := Typed[string](tr).Get("/a"); !ok || v != "redacted" {
+		t.Errorf("Get returned %q, %v", v, ok)
+	}
+}
@@ -0,0 +1,81 @@
+package statetrc
+
+// Region represents a state entered using Enter. Leaving the state through the Region
+// rather than by calling Leave with the id avoids bugs caused by mismatched ids.
+type Region struct {
+	t  *Tracer
+	id string
+	// inst identifies the instance in Multi mode
+	inst uint64
+}
+
+// Id returns the full id of the entry the Region represents.
+func (r *Region) Id() string {
+	if r == nil {
+		return ""
+	}
+	return r.id
+}
+
+// Leave removes the entry the Region represents. In Multi mode only the instance created
+// by the Enter call that returned the Region is removed. It is safe to call Leave on a nil Region.
+func (r *Region) Leave() {
+	if r == nil {
+		return
+	}
+	r.t.leave(r.id, r.inst)
+}
+
+// Rename renames the entry the Region represents to newID, as Tracer.Rename does, so that
+// later calls to Leave remove the renamed entry.
+func (r *Region) Rename(newID string) error {
+	if r == nil {
+		return nil
+	}
+	newID = r.t.full(newID)
+	if err := r.t.rename(r.id, newID); err != nil {
+		return err
+	}
+	r.id = newID
+	return nil
+}
+
+// EnterChild enters the state id as a child of the entry the Region represents.
+func (r *Region) EnterChild(id string, props interface{}) *Region {
+	if r == nil {
+		return nil
+	}
+	return r.t.enter(Entry{Id: id, Parent: r.id[len(r.t.prefix):], Props: props})
+}
+
+// Close is the same as Leave. It allows a Region to be used as an io.Closer.
+func (r *Region) Close() error {
+	r.Leave()
+	return nil
+}
+
+// Trace enters the state id with the passed properties and returns a function that leaves it.
+// It is intended for tracing function entry and exit in one statement:
+//
+//	defer statetrc.Trace("/myfunc", nil)()
+//
+// If SetRuntimeTrace is enabled it also starts a runtime/trace region, so the returned
+// function must be called on the same goroutine.
+func (t *Tracer) Trace(id string, props interface{}) func() {
+	r := t.Enter(id, props)
+	if r == nil {
+		return r.Leave
+	}
+	if end := t.startRegion(r.id); end != nil {
+		return func() {
+			end()
+			r.Leave()
+		}
+	}
+	return r.Leave
+}
+
+// Trace calls Trace on the default Tracer.
+func Trace(id string, props interface{}) func() {
+	return std.Trace(id, props)
+}
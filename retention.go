@@ -0,0 +1,162 @@
+package statetrc
+
+import (
+	"sort"
+	"time"
+)
+
+// Retention overrides how much is kept for the completed entries under a prefix by the history
+// and the statistics.
+type Retention struct {
+	// HistorySize is the number of completed entries under the prefix that are kept in the
+	// history apart from the others, so that frequent states do not push rare ones out. If zero
+	// they share the history set by SetHistorySize.
+	HistorySize int
+	// StatsInterval is the interval at which the statistics of the groups under the prefix are
+	// discarded. If zero the interval set by SetStatsResetInterval is used.
+	StatsInterval time.Duration
+}
+
+// retention is a Retention set by SetRetention with the history it keeps.
+type retention struct {
+	Retention
+	history ring[Entry]
+}
+
+// SetRetention sets the Retention for the completed entries under the path prefix, replacing the
+// one set before for the same prefix, if any. Where the prefixes of several Retentions match an
+// id the longest is used. Passing a zero Retention removes the one for prefix. Changing the
+// HistorySize discards the completed entries kept for prefix.
+func (t *Tracer) SetRetention(prefix string, r Retention) {
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if r == (Retention{}) {
+		delete(t.retention, prefix)
+		return
+	}
+	if t.retention == nil {
+		t.retention = map[string]*retention{}
+	}
+	o, ok := t.retention[prefix]
+	if !ok {
+		o = &retention{}
+		t.retention[prefix] = o
+	}
+	if !ok || o.HistorySize != r.HistorySize {
+		o.history.resize(r.HistorySize)
+	}
+	o.Retention = r
+}
+
+// SetRetention calls SetRetention on the default Tracer.
+func SetRetention(prefix string, r Retention) {
+	std.SetRetention(prefix, r)
+}
+
+// SetStatsResetInterval makes the statistics of each group, as set by SetStatsDepth, be
+// discarded when d has passed since they were started, so that long-running services report
+// recent durations rather than accumulating them forever. Passing zero keeps them until
+// ResetStats is called.
+func (t *Tracer) SetStatsResetInterval(d time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.statsInterval = d
+}
+
+// SetStatsResetInterval calls SetStatsResetInterval on the default Tracer.
+func SetStatsResetInterval(d time.Duration) {
+	std.SetStatsResetInterval(d)
+}
+
+// ResetStats discards the statistics of all groups. For a namespace only the groups under its
+// prefix are discarded.
+func (t *Tracer) ResetStats() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for key := range t.stats {
+		if t.inScope(key) {
+			delete(t.stats, key)
+		}
+	}
+}
+
+// ResetStats calls ResetStats on the default Tracer.
+func ResetStats() {
+	std.ResetStats()
+}
+
+// ResetHistory discards the recorded completed entries. For a namespace only the entries under
+// its prefix are discarded.
+func (t *Tracer) ResetHistory() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	keep := func(e Entry) bool { return !t.inScope(e.Id) }
+	t.history.filter(keep)
+	for _, r := range t.retention {
+		r.history.filter(keep)
+	}
+}
+
+// ResetHistory calls ResetHistory on the default Tracer.
+func ResetHistory() {
+	std.ResetHistory()
+}
+
+// retentionLocked returns the retention with the longest prefix of id, or nil if there is none.
+// t.mtx must be held.
+func (c *core) retentionLocked(id string) *retention {
+	var res *retention
+	longest := -1
+	for prefix, r := range c.retention {
+		if len(prefix) > longest && hasPathPrefix(id, prefix) {
+			res, longest = r, len(prefix)
+		}
+	}
+	return res
+}
+
+// addHistoryLocked records the completed entry e in the history kept for it. t.mtx must be held.
+func (c *core) addHistoryLocked(e Entry) {
+	if r := c.retentionLocked(e.Id); r != nil && r.HistorySize > 0 {
+		r.history.add(e)
+		return
+	}
+	c.history.add(e)
+}
+
+// historyLocked returns the recorded completed entries, oldest first. t.mtx must be held.
+func (c *core) historyLocked() []Entry {
+	l := c.history.list()
+	if len(c.retention) == 0 {
+		return l
+	}
+	for _, r := range c.retention {
+		l = append(l, r.history.list()...)
+	}
+	sort.SliceStable(l, func(i, j int) bool { return l[i].EndTime.Before(l[j].EndTime) })
+	return l
+}
+
+// statsExpiredLocked reports whether the statistics s of the group key were started longer ago
+// than the interval at which they are discarded. t.mtx must be held.
+func (c *core) statsExpiredLocked(key string, s *durationStats, now time.Time) bool {
+	d := c.statsInterval
+	if r := c.retentionLocked(key); r != nil && r.StatsInterval > 0 {
+		d = r.StatsInterval
+	}
+	return d > 0 && now.Sub(s.since) >= d
+}
+
+// expireStatsLocked discards the statistics that are due to be discarded. t.mtx must be held.
+func (c *core) expireStatsLocked() {
+	if c.statsInterval <= 0 && len(c.retention) == 0 {
+		return
+	}
+	now := c.nowLocked()
+	for key, s := range c.stats {
+		if c.statsExpiredLocked(key, s, now) {
+			delete(c.stats, key)
+		}
+	}
+}
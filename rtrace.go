@@ -0,0 +1,64 @@
+package statetrc
+
+import (
+	"context"
+	"runtime/trace"
+)
+
+// runtimeTask is the runtime/trace task of an entry.
+type runtimeTask struct {
+	ctx  context.Context
+	task *trace.Task
+}
+
+// SetRuntimeTrace makes the Tracer create a runtime/trace task for each entry while an execution
+// trace is being recorded, so that states appear in go tool trace alongside the scheduling of
+// goroutines. The task of an entry created with EnterChild is a subtask of its parent's task, and
+// that of an entry created with EnterCtx is a subtask of any task in the context. Trace also
+// starts a region in the task, which must be ended on the same goroutine as Trace is called on.
+func (t *Tracer) SetRuntimeTrace(on bool) {
+	t.runtimeTrace.Store(on)
+}
+
+// SetRuntimeTrace calls SetRuntimeTrace on the default Tracer.
+func SetRuntimeTrace(on bool) {
+	std.SetRuntimeTrace(on)
+}
+
+// startTaskLocked starts the runtime/trace task of e if enabled. t.mtx must be held.
+func (c *core) startTaskLocked(e *Entry) {
+	if !c.runtimeTrace.Load() || !trace.IsEnabled() {
+		return
+	}
+	ctx := e.ctx
+	if p, ok := c.entries[e.Parent]; ok && p.rt != nil {
+		ctx = p.rt.ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	tctx, task := trace.NewTask(ctx, e.Id)
+	e.rt = &runtimeTask{ctx: tctx, task: task}
+}
+
+// endTask ends the runtime/trace task of e, if it has one.
+func (e *Entry) endTask() {
+	if e.rt != nil {
+		e.rt.task.End()
+	}
+}
+
+// startRegion starts a runtime/trace region for the entry with the full id if enabled, and
+// returns the function that ends it, or nil.
+func (t *Tracer) startRegion(id string) func() {
+	if !t.runtimeTrace.Load() || !trace.IsEnabled() {
+		return nil
+	}
+	ctx := context.Background()
+	t.mtx.Lock()
+	if e, ok := t.entries[id]; ok && e.rt != nil {
+		ctx = e.rt.ctx
+	}
+	t.mtx.Unlock()
+	return trace.StartRegion(ctx, id).End
+}
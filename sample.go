@@ -0,0 +1,35 @@
+package statetrc
+
+import (
+	"math/rand"
+	"time"
+)
+
+// SetSampling makes the history set by SetHistorySize and the statistics set by SetStatsDepth
+// record only about one in every n completed entries, chosen at random, so that states entered
+// at a high rate do not use too much memory or load the exporters. Completed entries whose
+// duration is at least slow are always recorded, so the slow outliers are kept; a slow of zero
+// samples all entries alike. Since the sample is biased towards such entries, the statistics are
+// then only an upper bound of the real ones. Passing n of one or less records every entry.
+// Hooks and subscriptions are not affected.
+func (t *Tracer) SetSampling(n int, slow time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.sampleEvery, t.sampleSlow = n, slow
+}
+
+// SetSampling calls SetSampling on the default Tracer.
+func SetSampling(n int, slow time.Duration) {
+	std.SetSampling(n, slow)
+}
+
+// sampledLocked reports whether the completed entry e is to be recorded. t.mtx must be held.
+func (c *core) sampledLocked(e Entry) bool {
+	if c.sampleEvery <= 1 {
+		return true
+	}
+	if c.sampleSlow > 0 && e.EndTime.Sub(e.Time) >= c.sampleSlow {
+		return true
+	}
+	return rand.Intn(c.sampleEvery) == 0
+}
@@ -0,0 +1,24 @@
+package statetrc
+
+// Range calls fn for each currently existing entry, in no particular order, until fn returns
+// false. Unlike List it does not copy the entries into a slice, which makes it suitable for
+// exporters that scan all entries frequently. fn is called with the Tracer locked, so it must
+// not call methods of the Tracer and should return quickly.
+func (t *Tracer) Range(fn func(e Entry) bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	for _, e := range t.entries {
+		if !t.inScope(e.Id) {
+			continue
+		}
+		if !fn(t.redactLocked(e)) {
+			return
+		}
+	}
+}
+
+// Range calls Range on the default Tracer.
+func Range(fn func(e Entry) bool) {
+	std.Range(fn)
+}
@@ -0,0 +1,46 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	tr := NewTracer()
+	for _, id := range []string{"/a", "/b", "/ns/c"} {
+		tr.Enter(id, nil)
+	}
+	tests := []struct {
+		name string
+		t    *Tracer
+		// stop is the number of entries after which fn returns false, or zero for none
+		stop int
+		want []string
+	}{
+		{"all", tr, 0, []string{"/a", "/b", "/ns/c"}},
+		{"stop", tr, 1, nil},
+		{"namespace", tr.Namespace("/ns"), 0, []string{"/ns/c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			tt.t.Range(func(e Entry) bool {
+				got = append(got, e.Id)
+				return len(got) != tt.stop
+			})
+			if tt.stop > 0 {
+				if len(got) != tt.stop {
+					t.Errorf("got %d entries, want %d", len(got), tt.stop)
+				}
+				return
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
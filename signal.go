@@ -0,0 +1,66 @@
+package statetrc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime/pprof"
+	"sync"
+)
+
+// DumpOnSignal writes the entries to w, as Dump does with a TextFormatter, each time the process
+// receives sig, such as syscall.SIGUSR1. This shows the state of a hung process that has no
+// debug port. It returns a function that stops handling the signal. Handling SIGQUIT this way
+// replaces the default behavior of exiting with a dump of the goroutines.
+func (t *Tracer) DumpOnSignal(sig os.Signal, w io.Writer) func() {
+	return t.dumpOnSignal(sig, w, false)
+}
+
+// DumpOnSignal calls DumpOnSignal on the default Tracer.
+func DumpOnSignal(sig os.Signal, w io.Writer) func() {
+	return std.DumpOnSignal(sig, w)
+}
+
+// DumpOnSignalStacks is like DumpOnSignal, but also writes the stacks of all goroutines after
+// the entries.
+func (t *Tracer) DumpOnSignalStacks(sig os.Signal, w io.Writer) func() {
+	return t.dumpOnSignal(sig, w, true)
+}
+
+// DumpOnSignalStacks calls DumpOnSignalStacks on the default Tracer.
+func DumpOnSignalStacks(sig os.Signal, w io.Writer) func() {
+	return std.DumpOnSignalStacks(sig, w)
+}
+
+func (t *Tracer) dumpOnSignal(sig os.Signal, w io.Writer, stacks bool) func() {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sig)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case s := <-ch:
+				snap := t.snapshot(nil, nil)
+				fmt.Fprintf(w, "statetrc: %v received at %s, %d entries\n", s,
+					snap.At.Format("2006-01-02 15:04:05.000"), len(snap.Entries))
+				snap.Render(w, TextFormatter{})
+				if stacks {
+					fmt.Fprintln(w, "\nstatetrc: goroutines")
+					pprof.Lookup("goroutine").WriteTo(w, 2)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package statetrc
+
+import "time"
+
+// Snapshot is a list of entries along with the time it was captured. Ages of entries in a
+// Snapshot are computed relative to At, so that they are consistent with each other no matter
+// how long it takes to format them.
+type Snapshot struct {
+	// Time the Snapshot was captured, according to the Tracer's clock
+	At time.Time
+	// Entries that existed when the Snapshot was captured
+	Entries EntrySlice
+}
+
+// String formats the entries in the Snapshot like EntrySlice.String, with ages relative to At.
+func (s Snapshot) String() string {
+	return s.Entries.format(s.At)
+}
+
+// Capture returns a Snapshot of all currently existing entries, ordered in the specified Order.
+func (t *Tracer) Capture(order Order) Snapshot {
+	return t.snapshot(nil, order)
+}
+
+// Capture calls Capture on the default Tracer.
+func Capture(order Order) Snapshot {
+	return std.Capture(order)
+}
+
+// Diff compares two snapshots, normally a taken before b. added contains the entries of b that
+// are not in a, removed the entries of a that are not in b, and persisted the entries of b that
+// were already in a. Entries are the same if they have the same Id and Seq, so a state that was
+// left and entered again between the snapshots is reported as both removed and added. Entries
+// that persist across snapshots taken some time apart are likely to be stuck.
+func Diff(a, b Snapshot) (added, removed, persisted EntrySlice) {
+	type key struct {
+		id  string
+		seq uint64
+	}
+
+	inA := make(map[key]bool, len(a.Entries))
+	for _, e := range a.Entries {
+		inA[key{e.Id, e.Seq}] = true
+	}
+
+	inB := make(map[key]bool, len(b.Entries))
+	for _, e := range b.Entries {
+		k := key{e.Id, e.Seq}
+		inB[k] = true
+		if inA[k] {
+			persisted = append(persisted, e)
+		} else {
+			added = append(added, e)
+		}
+	}
+
+	for _, e := range a.Entries {
+		if !inB[key{e.Id, e.Seq}] {
+			removed = append(removed, e)
+		}
+	}
+	return
+}
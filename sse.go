@@ -0,0 +1,102 @@
+package statetrc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SSEHandler returns an http.Handler that streams changes to the entries as Server-Sent Events,
+// so that a browser or curl can watch the state of the program without polling. A "snapshot"
+// event with the JSON encoding of a Snapshot is sent first, followed by "enter", "leave" and
+// "update" events with the JSON encoding of each Event. The URL query parameters are:
+//
+//	prefix=P     only entries under the path prefix P
+//	snapshot=D   also send a snapshot every D, such as 10s
+//
+// As for Watch, events are dropped if the client falls too far behind.
+func (t *Tracer) SSEHandler() http.Handler {
+	return t.authorize(func(w http.ResponseWriter, r *http.Request) {
+		var prefix string
+		var interval time.Duration
+		for key, vals := range r.URL.Query() {
+			var err error
+			switch key {
+			case "prefix":
+				prefix = vals[0]
+			case "snapshot":
+				interval, err = time.ParseDuration(vals[0])
+			default:
+				err = fmt.Errorf("unknown parameter")
+			}
+			if err != nil {
+				http.Error(w, fmt.Sprintf("statetrc: bad parameter %s: %v", key, err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "statetrc: streaming not supported", http.StatusInternalServerError)
+			return
+		}
+
+		ch, stop := t.Watch(prefix)
+		defer stop()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+
+		snapshot := func() error {
+			return writeSSE(w, "snapshot", t.runQuery(&query{prefix: prefix}))
+		}
+		if snapshot() != nil {
+			return
+		}
+		flusher.Flush()
+
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			var err error
+			select {
+			case <-r.Context().Done():
+				return
+			case ev, ok := <-ch:
+				if !ok {
+					return
+				}
+				err = writeSSE(w, ev.Type.String(), ev)
+			case <-tick:
+				err = snapshot()
+			}
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	})
+}
+
+// SSEHandler calls SSEHandler on the default Tracer.
+func SSEHandler() http.Handler {
+	return std.SSEHandler()
+}
+
+// writeSSE writes an event with the JSON encoding of v as its data.
+func writeSSE(w io.Writer, event string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
@@ -0,0 +1,84 @@
+package statetrc
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// stallState is how an entry looked in the previous capture of DetectStalls, and in how many
+// consecutive captures it looked the same.
+type stallState struct {
+	e    Entry
+	n    int
+	sent bool
+}
+
+// DetectStalls starts a goroutine that captures the entries every interval and sends each entry
+// that was unchanged in minPersist consecutive captures on the returned channel, once. An entry
+// is unchanged if it was neither left nor entered again and its props, Labels, Time and Count
+// stayed the same, so states that report progress with Update are not considered stalled. For
+// bursty workloads this is a more robust sign of a stuck state than a single age threshold.
+// If interval is zero or less 10 seconds is used. The returned function stops the detection
+// and closes the channel.
+//
+// Entries are sent without blocking, so if the receiver falls more than WatchBuffer entries
+// behind, further entries are dropped until it catches up.
+func (t *Tracer) DetectStalls(interval time.Duration, minPersist int) (<-chan Entry, func()) {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	if minPersist < 1 {
+		minPersist = 1
+	}
+	ch := make(chan Entry, WatchBuffer)
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(ch)
+		defer ticker.Stop()
+		seen := map[string]*stallState{}
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			s := t.snapshot(nil, ByAge)
+			cur := make(map[string]*stallState, len(s.Entries))
+			for _, e := range s.Entries {
+				st := seen[e.Id]
+				if st == nil || !sameState(st.e, e) {
+					st = &stallState{e: e}
+				}
+				st.n++
+				cur[e.Id] = st
+				if st.n >= minPersist && !st.sent {
+					st.sent = true
+					select {
+					case ch <- e:
+					default:
+					}
+				}
+			}
+			seen = cur
+		}
+	}()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// DetectStalls calls DetectStalls on the default Tracer.
+func DetectStalls(interval time.Duration, minPersist int) (<-chan Entry, func()) {
+	return std.DetectStalls(interval, minPersist)
+}
+
+// sameState reports whether a and b, captured at different times, show the same unchanged state.
+func sameState(a, b Entry) bool {
+	return a.Seq == b.Seq && a.Time.Equal(b.Time) && a.Count == b.Count &&
+		reflect.DeepEqual(a.Props, b.Props) && reflect.DeepEqual(a.Labels, b.Labels)
+}
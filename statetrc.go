@@ -2,21 +2,211 @@
 // record it. When the state is left, use Leave to remove the state. At any time List can be used to obtain a
 // list of all the existing entries, which is a snapshot of the current state of the program. This can be useful
 // in debugging to tell what functions or higher-level states are stuck or taking a long time to complete.
+//
+// The package-level functions operate on a default Tracer. Independent sets of entries (for example one per
+// subsystem or per test) can be kept by creating additional Tracers with NewTracer.
+//
+// When built with the statetrc_off build tag, the functions that enter, leave and update
+// entries compile to empty functions that can be inlined away, so that instrumentation can
+// be shipped with no runtime cost.
 package statetrc
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-var (
-	entries = map[string]Entry{}
-	mtx     sync.Mutex
+// Tracer holds an independent set of entries. Tracers must be created with NewTracer.
+// A Tracer is safe for concurrent use by multiple goroutines.
+type Tracer struct {
+	*core
+	// prefix is prepended to ids by Tracers returned from Namespace
+	prefix string
+}
+
+// core is the state shared by a Tracer and its namespaces.
+type core struct {
+	entries map[string]Entry
+	// instances holds the instances of entries entered in Multi mode
+	instances map[string][]instance
+	// seq is the last sequence number assigned to an entry or instance
+	seq      uint64
+	mode     Mode
+	unique   uint64
+	clock    func() time.Time
+	misuse   func(err error)
+	redact   Redactor
+	auth     Authorizer
+	watchers []*watcher
+	diag     DiagnosticCounters
+	// completedSubs are the subscriptions made with SubscribeCompleted
+	completedSubs []*completedSub
+	// onEnter and onLeave are the hooks registered with OnEnter and OnLeave. hookCalls holds
+	// the calls of the hooks to make when mtx is unlocked.
+	onEnter   []*enterHook
+	onLeave   []*leaveHook
+	hookCalls []hookCall
+	// history holds completed entries if enabled with SetHistorySize
+	history ring[Entry]
+	// events holds the recent events if enabled with SetEventLogSize
+	events ring[Event]
+	// stats holds the statistics of completed entries by group if enabled with SetStatsDepth
+	stats      map[string]*durationStats
+	statsDepth int
+	// statsInterval is the interval set by SetStatsResetInterval, and retention holds the
+	// Retentions set by SetRetention by prefix
+	statsInterval time.Duration
+	retention     map[string]*retention
+	// sampleEvery and sampleSlow set which completed entries are recorded, as set by SetSampling
+	sampleEvery int
+	sampleSlow  time.Duration
+	// buckets are the bounds of the histograms set by SetHistogramBuckets
+	buckets []time.Duration
+	// highWater holds the high-water marks by prefix if enabled with SetHighWaterDepth
+	highWater      map[string]*HighWaterMark
+	highWaterDepth int
+	// occupancy tracks the time with entries by prefix if enabled with SetOccupancyWindow,
+	// which was last called at occupancyStart
+	occupancy       map[string]*occupancy
+	occupancyDepth  int
+	occupancyWindow time.Duration
+	occupancyStart  time.Time
+	// budgets holds the budgets set by SetBudget by prefix
+	budgets map[string]*budget
+	// rates counts the calls by prefix if enabled with SetRateWindow, which was last called at
+	// rateStart
+	rates      map[string]*rateCounter
+	rateDepth  int
+	rateWindow time.Duration
+	rateStart  time.Time
+	// leaks holds the thresholds set by SetLeakThreshold by prefix. leakStop stops the
+	// goroutine checking them.
+	leaks    map[string]*leakThreshold
+	leakStop chan struct{}
+	// nextExpiry is the earliest Expires of the entries, or zero if no entry expires.
+	// It may be earlier than the actual earliest if entries have been removed.
+	nextExpiry time.Time
+	// disabled and offPrefixes are read without holding mtx so that disabled
+	// tracing is cheap.
+	disabled    atomic.Bool
+	offPrefixes atomic.Pointer[[]string]
+	// runtimeTrace and pprofLabels are set by SetRuntimeTrace and SetPprofLabels
+	runtimeTrace atomic.Bool
+	pprofLabels  atomic.Bool
+	mtx          sync.Mutex
+}
+
+// instance is one of several concurrent entries of the same id in Multi mode.
+type instance struct {
+	seq  uint64
+	time time.Time
+}
+
+// Mode controls what happens when an id that already exists is entered again.
+type Mode int
+
+const (
+	// Overwrite replaces the existing entry with the new one. This is the default.
+	Overwrite Mode = iota
+	// RefCount increments the Count of the existing entry, keeping its Time and Props. Leave decrements
+	// the Count and the entry is only removed when it reaches zero.
+	RefCount
+	// Multi records each Enter of an existing id as a separate instance with its own start time.
+	// The entry's Count is the number of instances and its Time is the start of the oldest instance.
+	// Leave removes the most recently entered instance, while Region.Leave removes the instance
+	// the Region was created for.
+	Multi
 )
 
+// NewTracer returns a new Tracer with no entries.
+func NewTracer() *Tracer {
+	return &Tracer{core: &core{entries: map[string]Entry{}, instances: map[string][]instance{}}}
+}
+
+// Namespace returns a view of t whose methods prepend prefix to all ids they are passed, and
+// whose listing, counting and clearing methods only consider entries under prefix. Entries returned
+// by the view still carry their full ids. The view shares its entries and settings with t, so
+// a library can be passed a namespace of the application's Tracer without its ids colliding with
+// the application's.
+//
+// The prefix is prepended as is, so it should normally be of the form /name.
+func (t *Tracer) Namespace(prefix string) *Tracer {
+	return &Tracer{core: t.core, prefix: t.prefix + prefix}
+}
+
+// Namespace calls Namespace on the default Tracer.
+func Namespace(prefix string) *Tracer {
+	return std.Namespace(prefix)
+}
+
+// full returns the full id for an id passed to a method of t.
+func (t *Tracer) full(id string) string {
+	return t.prefix + id
+}
+
+// inScope returns true if the entry with the full id is visible through t.
+func (t *Tracer) inScope(id string) bool {
+	return t.prefix == "" || hasPathPrefix(id, t.prefix)
+}
+
+// SetMode sets how entering an existing id is handled. The default is Overwrite.
+// The mode is shared with all namespaces of the Tracer.
+func (t *Tracer) SetMode(m Mode) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.mode = m
+}
+
+// SetMode calls SetMode on the default Tracer.
+func SetMode(m Mode) {
+	std.SetMode(m)
+}
+
+// SetClock sets the function used to obtain the current time, which is time.Now by default.
+// Passing a fixed or manually advanced clock allows tests of instrumented code to make exact
+// assertions about entry times and ages. Passing nil restores time.Now. The clock is shared
+// with all namespaces of the Tracer.
+func (t *Tracer) SetClock(clock func() time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.clock = clock
+}
+
+// SetClock calls SetClock on the default Tracer.
+func SetClock(clock func() time.Time) {
+	std.SetClock(clock)
+}
+
+// nowLocked returns the current time according to the Tracer's clock. t.mtx must be held.
+func (c *core) nowLocked() time.Time {
+	if c.clock != nil {
+		return c.clock()
+	}
+	return time.Now()
+}
+
+// Now returns the current time according to the Tracer's clock.
+func (t *Tracer) Now() time.Time {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.nowLocked()
+}
+
+// std is the Tracer used by the package-level functions.
+var std = NewTracer()
+
+// Default returns the Tracer used by the package-level functions.
+func Default() *Tracer {
+	return std
+}
+
 // Entry represents a single event in the trace. Usually used to
 // represent entering some state.
 type Entry struct {
@@ -26,30 +216,105 @@ type Entry struct {
 	Props interface{}
 	// Time when the Entry was added
 	Time time.Time
+	// Time when the state was left. It is zero for entries that are still active, and is
+	// set for entries that report completed states.
+	EndTime time.Time
+	// Id of the parent entry, if the Entry was created with EnterChild
+	Parent string
+	// Structured properties. Unlike Props these can be used for filtering and formatting.
+	// The map must not be modified.
+	Labels map[string]string
+	// Time after which the Entry is removed automatically, if it was created with the WithTTL option
+	Expires time.Time
+	// ttl is set by WithTTL and converted to Expires when the Entry is added
+	ttl time.Duration
+	// Stack of the goroutine that created the Entry, if it was created with the WithStack option
+	Stack string
+	// Sequence number assigned when the Entry was added. Sequence numbers increase monotonically
+	// within a Tracer, so they tell which of two entries started first even when their Times are equal.
+	Seq uint64
+	// Number of times the state is currently entered. It is always 1 unless the Tracer is in RefCount or Multi mode.
+	Count int
+	// ctx is the context passed to EnterCtx or WithContext
+	ctx context.Context
+	// rt is the runtime/trace task of the Entry, if SetRuntimeTrace is enabled
+	rt *runtimeTask
+}
+
+// Context returns the context the Entry was entered with by EnterCtx or the WithContext option,
+// or nil. It lets exporters relate entries to the request or trace they belong to.
+func (e Entry) Context() context.Context {
+	return e.ctx
+}
+
+// Age returns how long the state has been active as of now. For a completed Entry, which
+// has an EndTime, it returns the time between Time and EndTime instead.
+func (e Entry) Age(now time.Time) time.Duration {
+	if !e.EndTime.IsZero() {
+		return e.EndTime.Sub(e.Time)
+	}
+	return now.Sub(e.Time)
 }
 
 type EntrySlice []Entry
 
 func (e EntrySlice) String() string {
+	return e.format(time.Now())
+}
+
+// format formats the entries with ages relative to now, as String does, using DefaultFormatOptions.
+func (e EntrySlice) format(now time.Time) string {
 	var buf bytes.Buffer
-	now := time.Now()
 
 	for _, e := range e {
-		d := now.Sub(e.Time)
-		fmt.Fprintf(&buf, "%s: %v\n", e.Id, d)
-		props := fmt.Sprintf("%v", e.Props)
-
-		// Indent each line in props by two spaces when printing
-		buf.WriteString("  ")
-		for _, r := range props {
-			buf.WriteRune(r)
-			if r == '\n' {
-				buf.WriteString("  ")
-			}
+		writeEntry(&buf, e, now, &DefaultFormatOptions)
+	}
+
+	return buf.String()
+}
+
+// writeEntry writes e to buf in the format used by EntrySlice.String.
+func writeEntry(buf *bytes.Buffer, e Entry, now time.Time, o *FormatOptions) {
+	buf.WriteString(e.Id)
+	if e.Count > 1 {
+		fmt.Fprintf(buf, " (x%d)", e.Count)
+	}
+	fmt.Fprintf(buf, ": %s", o.duration(e.Age(now)))
+	if o.ShowStart {
+		fmt.Fprintf(buf, " (started %s)", o.start(e.Time))
+	}
+	buf.WriteRune('\n')
+	if len(e.Labels) > 0 {
+		fmt.Fprintf(buf, "  %s\n", formatLabels(e.Labels))
+	}
+	props := o.props(fmt.Sprintf("%v", e.Props))
+
+	// Indent each line in props by two spaces when printing
+	buf.WriteString("  ")
+	for _, r := range props {
+		buf.WriteRune(r)
+		if r == '\n' {
+			buf.WriteString("  ")
 		}
-		buf.WriteRune('\n')
 	}
+	buf.WriteRune('\n')
+}
+
+// formatLabels formats labels as space-separated key=value pairs ordered by key.
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
+	var buf bytes.Buffer
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteRune(' ')
+		}
+		fmt.Fprintf(&buf, "%s=%s", k, labels[k])
+	}
 	return buf.String()
 }
 
@@ -59,17 +324,396 @@ func (e EntrySlice) String() string {
 // This allows using the package for function entry/exit (use /funcname)
 // but also for items in a set (/itemtype/id1, /itemtype/id2) which is useful
 // for counting how many things are there in a set, etc.
-func Enter(id string, props interface{}) {
-	mtx.Lock()
-	defer mtx.Unlock()
-	entries[id] = Entry{Id: id, Props: props, Time: time.Now()}
+//
+// If tracing is disabled for id Enter does nothing and returns nil, which is
+// safe to use as a Region.
+//
+// The returned Region may be used to leave the state without repeating the id:
+//
+//	r := statetrc.Enter("/myfunc", nil)
+//	defer r.Leave()
+func (t *Tracer) Enter(id string, props interface{}) *Region {
+	return t.enter(Entry{Id: id, Props: props})
+}
+
+// Enter calls Enter on the default Tracer.
+func Enter(id string, props interface{}) *Region {
+	return std.Enter(id, props)
+}
+
+// EnterL creates a new Entry like Enter, with structured labels instead of free-form properties.
+func (t *Tracer) EnterL(id string, labels map[string]string) *Region {
+	return t.enter(Entry{Id: id, Labels: copyLabels(labels)})
+}
+
+// EnterL calls EnterL on the default Tracer.
+func EnterL(id string, labels map[string]string) *Region {
+	return std.EnterL(id, labels)
+}
+
+// EnterChild creates a new Entry like Enter, recording parentID as its parent. This allows
+// entries to form a tree independent of the path structure of their ids; see ListNested.
+func (t *Tracer) EnterChild(parentID, id string, props interface{}) *Region {
+	return t.enter(Entry{Id: id, Parent: parentID, Props: props})
+}
+
+// EnterChild calls EnterChild on the default Tracer.
+func EnterChild(parentID, id string, props interface{}) *Region {
+	return std.EnterChild(parentID, id, props)
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	c := make(map[string]string, len(labels))
+	for k, v := range labels {
+		c[k] = v
+	}
+	return c
+}
+
+// enter adds the entry n, which has its Id and properties set, according to the Tracer's Mode.
+// If n.Time is set it is used as the start time instead of the current time.
+func (t *Tracer) enter(n Entry) *Region {
+	if compiledOut {
+		return nil
+	}
+	if !t.prepare(&n) {
+		return nil
+	}
+	t.mtx.Lock()
+	t.expireLocked()
+	r, replaced := t.enterLocked(n)
+	misuse := t.misuse
+	t.unlock()
+
+	if replaced && misuse != nil {
+		misuse(fmt.Errorf("%w: %s", ErrExists, n.Id))
+	}
+	return r
+}
+
+// prepare converts the ids in n to full ids. It returns false if tracing is
+// disabled for the entry.
+func (t *Tracer) prepare(n *Entry) bool {
+	n.Id = t.full(n.Id)
+	if t.off(n.Id) {
+		return false
+	}
+	if n.Parent != "" {
+		n.Parent = t.full(n.Parent)
+	}
+	return true
+}
+
+// EnterIfAbsent creates a new Entry like Enter, but only if no entry with the id exists.
+// It returns true if the entry was created. This allows idempotent instrumentation of code
+// paths that may race to declare the same state.
+func (t *Tracer) EnterIfAbsent(id string, props interface{}) bool {
+	if compiledOut {
+		return false
+	}
+	n := Entry{Id: id, Props: props}
+	if !t.prepare(&n) {
+		return false
+	}
+	t.mtx.Lock()
+	defer t.unlock()
+	t.expireLocked()
+	if _, ok := t.entries[n.Id]; ok {
+		return false
+	}
+	t.enterLocked(n)
+	return true
+}
+
+// EnterIfAbsent calls EnterIfAbsent on the default Tracer.
+func EnterIfAbsent(id string, props interface{}) bool {
+	return std.EnterIfAbsent(id, props)
+}
+
+// enterLocked implements enter for an entry with a full id. It returns true if an existing
+// entry was replaced in Overwrite mode. t.mtx must be held.
+func (t *Tracer) enterLocked(n Entry) (r *Region, replaced bool) {
+	id := n.Id
+	now := t.nowLocked()
+	start := now
+	if !n.Time.IsZero() {
+		start = n.Time
+	}
+	r = &Region{t: t, id: id}
+	t.diag.Enters++
+	t.countRateLocked(id, true)
+
+	e, ok := t.entries[id]
+	replaced = ok && t.mode == Overwrite
+	if replaced {
+		t.diag.Overwrites++
+	}
+	switch {
+	case ok && t.mode == RefCount:
+		e.Count++
+	case ok && t.mode == Multi:
+		l, ok := t.instances[id]
+		if !ok {
+			// The entry was created in another mode.
+			l = []instance{{time: e.Time}}
+		}
+		t.seq++
+		r.inst = t.seq
+		l = append(l, instance{seq: r.inst, time: start})
+		t.instances[id] = l
+		e.Count = len(l)
+		if start.Before(e.Time) {
+			e.Time = start
+		}
+	default:
+		if ok {
+			e.endTask()
+		} else {
+			t.countActiveLocked(id, 1, now)
+		}
+		e = n
+		t.startTaskLocked(&e)
+		e.Time = start
+		e.Count = 1
+		t.seq++
+		e.Seq = t.seq
+		if e.ttl > 0 {
+			e.Expires = now.Add(e.ttl)
+		}
+		if !e.Expires.IsZero() && (t.nextExpiry.IsZero() || e.Expires.Before(t.nextExpiry)) {
+			t.nextExpiry = e.Expires
+		}
+		delete(t.instances, id)
+		if t.mode == Multi {
+			r.inst = e.Seq
+			t.instances[id] = []instance{{seq: r.inst, time: start}}
+		}
+	}
+	t.entries[id] = e
+	t.emitLocked(EnterEvent, e, now)
+	t.enterHookLocked(e)
+	return r, replaced
+}
+
+// EnterUnique creates a new Entry like Enter, with an id formed by appending a unique
+// path element to prefix, and returns that id. For example EnterUnique("/conn", nil)
+// might return "/conn/17". This allows tracing many concurrent instances of the same
+// operation without the caller making up unique ids.
+func (t *Tracer) EnterUnique(prefix string, props interface{}) string {
+	if compiledOut {
+		return prefix
+	}
+	t.mtx.Lock()
+	t.unique++
+	n := t.unique
+	t.mtx.Unlock()
+
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	id := prefix + strconv.FormatUint(n, 10)
+	t.Enter(id, props)
+	return id
 }
 
-// Leave removes the entry with the specified id.
+// EnterUnique calls EnterUnique on the default Tracer.
+func EnterUnique(prefix string, props interface{}) string {
+	return std.EnterUnique(prefix, props)
+}
+
+// Leave removes the entry with the specified id. In RefCount mode the entry's Count is
+// decremented instead, and the entry is only removed when it reaches zero. In Multi mode
+// the most recently entered instance is removed.
+func (t *Tracer) Leave(id string) {
+	t.leave(t.full(id), 0)
+}
+
+// leave removes the entry with the specified full id. If inst is not zero only that
+// instance of a Multi mode entry is removed.
+func (t *Tracer) leave(id string, inst uint64) {
+	if compiledOut || t.off(id) {
+		return
+	}
+	t.mtx.Lock()
+	found := t.leaveLocked(id, inst)
+	misuse := t.misuse
+	t.unlock()
+
+	if !found && misuse != nil {
+		misuse(fmt.Errorf("%w: %s", ErrNotFound, id))
+	}
+}
+
+// leaveLocked implements leave. It returns false if there was no entry or
+// instance to leave. t.mtx must be held.
+func (t *Tracer) leaveLocked(id string, inst uint64) bool {
+	e, ok := t.entries[id]
+	if !ok {
+		t.diag.OrphanLeaves++
+		return false
+	}
+
+	if l := t.instances[id]; len(l) > 0 {
+		i := len(l) - 1
+		if inst != 0 {
+			for i = range l {
+				if l[i].seq == inst {
+					break
+				}
+			}
+			if l[i].seq != inst {
+				t.diag.OrphanLeaves++
+				return false
+			}
+		}
+		t.diag.Leaves++
+		t.countRateLocked(id, false)
+		left := l[i]
+		l = append(l[:i], l[i+1:]...)
+		if len(l) == 0 {
+			t.deleteLocked(id, true)
+			return true
+		}
+		t.instances[id] = l
+		e.Count = len(l)
+		e.Time = l[0].time
+		for _, in := range l {
+			if in.time.Before(e.Time) {
+				e.Time = in.time
+			}
+		}
+		t.entries[id] = e
+		now := t.nowLocked()
+		t.emitLocked(LeaveEvent, e, now)
+
+		done := e
+		done.Time, done.EndTime, done.Seq, done.Count = left.time, now, left.seq, 1
+		t.completeLocked(done)
+		return true
+	}
+
+	t.diag.Leaves++
+	t.countRateLocked(id, false)
+	if e.Count > 1 && t.mode == RefCount {
+		e.Count--
+		t.entries[id] = e
+		t.emitLocked(LeaveEvent, e, t.nowLocked())
+		return true
+	}
+	t.deleteLocked(id, true)
+	return true
+}
+
+// expireLocked removes the entries whose Expires time has passed. t.mtx must be held.
+func (c *core) expireLocked() {
+	if c.nextExpiry.IsZero() {
+		return
+	}
+	now := c.nowLocked()
+	if now.Before(c.nextExpiry) {
+		return
+	}
+
+	c.nextExpiry = time.Time{}
+	for id, e := range c.entries {
+		if e.Expires.IsZero() {
+			continue
+		}
+		if !now.Before(e.Expires) {
+			c.deleteLocked(id, false)
+		} else if c.nextExpiry.IsZero() || e.Expires.Before(c.nextExpiry) {
+			c.nextExpiry = e.Expires
+		}
+	}
+}
+
+// deleteLocked removes the entry with the specified id and all its instances. If completed
+// is true the state was left, rather than the entry being discarded, and it is recorded as a
+// completed entry. t.mtx must be held.
+func (c *core) deleteLocked(id string, completed bool) {
+	e, ok := c.entries[id]
+	if !ok {
+		return
+	}
+	delete(c.entries, id)
+	delete(c.instances, id)
+	e.endTask()
+
+	now := c.nowLocked()
+	c.countActiveLocked(id, -1, now)
+	e.EndTime = now
+	c.emitLocked(LeaveEvent, e, now)
+	if completed {
+		c.completeLocked(e)
+	}
+}
+
+// resetLocked removes all entries. t.mtx must be held.
+func (c *core) resetLocked() {
+	if len(c.watchers) > 0 || len(c.events.buf) > 0 || c.runtimeTrace.Load() {
+		for id := range c.entries {
+			c.deleteLocked(id, false)
+		}
+	}
+	c.entries = map[string]Entry{}
+	c.instances = map[string][]instance{}
+	c.nextExpiry = time.Time{}
+	for _, m := range c.highWater {
+		m.Current = 0
+	}
+	now := c.nowLocked()
+	for _, o := range c.occupancy {
+		c.setActiveLocked(o, 0, now)
+	}
+	for prefix, b := range c.budgets {
+		c.setBudgetActiveLocked(prefix, b, 0, now)
+	}
+}
+
+// countActiveLocked adds delta to the number of entries with the id id for the features that
+// track how many entries there are over time. t.mtx must be held.
+func (c *core) countActiveLocked(id string, delta int, now time.Time) {
+	c.countHighWaterLocked(id, delta, now)
+	c.countOccupancyLocked(id, delta, now)
+	c.countBudgetLocked(id, delta, now)
+}
+
+// Leave calls Leave on the default Tracer.
 func Leave(id string) {
-	mtx.Lock()
-	defer mtx.Unlock()
-	delete(entries, id)
+	std.Leave(id)
+}
+
+// LeavePrefix leaves all entries whose ids are under the path prefix, regardless of their
+// Count, and returns the number of entries removed. Each is counted as left as many times as
+// its Count. Entries for which tracing is disabled are not removed. See CountPrefix for how
+// prefixes are matched.
+func (t *Tracer) LeavePrefix(prefix string) int {
+	if compiledOut {
+		return 0
+	}
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.unlock()
+	n := 0
+	for id, e := range t.entries {
+		if hasPathPrefix(id, prefix) && !t.off(id) {
+			t.diag.Leaves += uint64(e.Count)
+			for i := 0; i < e.Count; i++ {
+				t.countRateLocked(id, false)
+			}
+			t.deleteLocked(id, true)
+			n++
+		}
+	}
+	return n
+}
+
+// LeavePrefix calls LeavePrefix on the default Tracer.
+func LeavePrefix(prefix string) int {
+	return std.LeavePrefix(prefix)
 }
 
 var (
@@ -80,43 +724,110 @@ var (
 		}
 	}
 
-	// ById is an ordering that may be passed to List to return Entries ordered by duration descending.
+	// ByDuration is an ordering that may be passed to List to return Entries ordered by duration
+	// ascending, that is with the newest first. It is the same as ByNewest; use ByAge for the oldest first.
 	ByDuration Order = func(l []Entry) func(i, j int) bool {
 		return func(i, j int) bool {
 			return l[i].Time.After(l[j].Time)
 		}
 	}
+
+	// BySeq is an ordering that may be passed to List to return Entries in the order they were added.
+	BySeq Order = func(l []Entry) func(i, j int) bool {
+		return func(i, j int) bool {
+			return l[i].Seq < l[j].Seq
+		}
+	}
 )
 
 type Order func(l []Entry) func(i, j int) bool
 
 // List returns a slice of all currently existing entries, ordered in the specified Order.
-func List(order Order) EntrySlice {
-	mtx.Lock()
+func (t *Tracer) List(order Order) EntrySlice {
+	return t.list(nil, order)
+}
 
-	res := make([]Entry, len(entries))
+// list returns the existing entries for which keep returns true, or all entries if keep
+// is nil, ordered in the specified Order. keep is called with t.mtx held.
+func (t *Tracer) list(keep func(e *Entry) bool, order Order) EntrySlice {
+	return t.snapshot(keep, order).Entries
+}
 
-	i := 0
-	for _, v := range entries {
-		res[i] = v
-		i++
+// snapshot is like list, but also returns the time the entries were copied.
+func (t *Tracer) snapshot(keep func(e *Entry) bool, order Order) Snapshot {
+	s := t.collect(keep)
+	if order == nil {
+		order = ById
 	}
+	sort.Slice(s.Entries, order(s.Entries))
+	return s
+}
 
-	mtx.Unlock()
+// collect is like snapshot, but leaves the entries in no particular order.
+func (t *Tracer) collect(keep func(e *Entry) bool) Snapshot {
+	t.mtx.Lock()
+	t.expireLocked()
+	at := t.nowLocked()
 
-	if order == nil {
-		order = ById
+	res := make([]Entry, 0, len(t.entries))
+
+	for _, v := range t.entries {
+		if t.inScope(v.Id) && (keep == nil || keep(&v)) {
+			res = append(res, t.redactLocked(v))
+		}
 	}
 
-	sort.Slice(res, order(res))
+	t.mtx.Unlock()
 
-	return res
+	return Snapshot{At: at, Entries: res}
+}
+
+// List calls List on the default Tracer.
+func List(order Order) EntrySlice {
+	return std.List(order)
 }
 
-// Clear removes all entries. It clears all state.
+// Clear removes all entries. It clears all state. For a namespace, only the entries
+// under its prefix are removed.
+func (t *Tracer) Clear() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.prefix != "" {
+		t.clearPrefixLocked(t.prefix)
+		return
+	}
+	t.resetLocked()
+}
+
+// Clear calls Clear on the default Tracer.
 func Clear() {
-	mtx.Lock()
-	defer mtx.Unlock()
+	std.Clear()
+}
 
-	entries = map[string]Entry{}
+// ClearPrefix removes the entries whose ids are under the path prefix as Clear does, without
+// leaving them, and returns the number of entries removed. See CountPrefix for how prefixes
+// are matched.
+func (t *Tracer) ClearPrefix(prefix string) int {
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.clearPrefixLocked(prefix)
+}
+
+// ClearPrefix calls ClearPrefix on the default Tracer.
+func ClearPrefix(prefix string) int {
+	return std.ClearPrefix(prefix)
+}
+
+// clearPrefixLocked implements ClearPrefix for a full prefix. t.mtx must be held.
+func (c *core) clearPrefixLocked(prefix string) int {
+	n := 0
+	for id := range c.entries {
+		if hasPathPrefix(id, prefix) {
+			c.deleteLocked(id, false)
+			n++
+		}
+	}
+	return n
 }
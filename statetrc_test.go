@@ -0,0 +1,304 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testClock is a clock for Tracer.SetClock that only advances when told to.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) Add(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestTracer returns a Tracer using a testClock.
+func newTestTracer() (*Tracer, *testClock) {
+	c := &testClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	t := NewTracer()
+	t.SetClock(c.Now)
+	return t, c
+}
+
+func ids(l EntrySlice) []string {
+	res := []string{}
+	for _, e := range l {
+		res = append(res, e.Id)
+	}
+	return res
+}
+
+func TestModes(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  Mode
+		count int
+		// props and start are those of /a after entering it twice
+		props interface{}
+		start time.Duration
+		// afterLeave is whether /a still exists after leaving it once
+		afterLeave bool
+	}{
+		{"Overwrite", Overwrite, 1, 2, time.Second, false},
+		{"RefCount", RefCount, 2, 1, 0, true},
+		{"Multi", Multi, 2, 2, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, clock := newTestTracer()
+			tr.SetMode(tt.mode)
+			start := clock.Now()
+			tr.Enter("/a", 1)
+			clock.Add(time.Second)
+			tr.Enter("/a", 2)
+
+			e, ok := tr.Get("/a")
+			if !ok {
+				t.Fatal("/a does not exist")
+			}
+			if e.Count != tt.count {
+				t.Errorf("Count = %d, want %d", e.Count, tt.count)
+			}
+			if tt.mode != Multi && e.Props != tt.props {
+				t.Errorf("Props = %v, want %v", e.Props, tt.props)
+			}
+			if want := start.Add(tt.start); !e.Time.Equal(want) {
+				t.Errorf("Time = %v, want %v", e.Time, want)
+			}
+
+			tr.Leave("/a")
+			if got := tr.Exists("/a"); got != tt.afterLeave {
+				t.Errorf("after one Leave Exists = %v, want %v", got, tt.afterLeave)
+			}
+			tr.Leave("/a")
+			if tr.Exists("/a") {
+				t.Error("after two Leaves /a still exists")
+			}
+		})
+	}
+}
+
+func TestMultiRegionLeave(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.SetMode(Multi)
+	first := tr.Enter("/a", nil)
+	clock.Add(time.Second)
+	tr.Enter("/a", nil)
+
+	first.Leave()
+	e, _ := tr.Get("/a")
+	if e.Count != 1 || !e.Time.Equal(clock.Now()) {
+		t.Errorf("after leaving the first instance got Count %d, Time %v", e.Count, e.Time)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.EnterWith("/short", WithTTL(time.Second))
+	tr.EnterWith("/long", WithTTL(time.Minute))
+	tr.Enter("/forever", nil)
+
+	clock.Add(2 * time.Second)
+	if got, want := ids(tr.List(ById)), []string{"/forever", "/long"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after 2s got %v, want %v", got, want)
+	}
+	clock.Add(time.Hour)
+	if got, want := ids(tr.List(ById)), []string{"/forever"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after 1h got %v, want %v", got, want)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(tr *Tracer)
+		want error
+	}{
+		{"enter twice", func(tr *Tracer) { tr.Enter("/a", nil); tr.Enter("/a", nil) }, ErrExists},
+		{"leave missing", func(tr *Tracer) { tr.Leave("/a") }, ErrNotFound},
+		{"leave batch missing", func(tr *Tracer) { tr.LeaveBatch([]string{"/a"}) }, ErrNotFound},
+		{"enter and leave", func(tr *Tracer) { tr.Enter("/a", nil); tr.Leave("/a") }, nil},
+		{"leave disabled", func(tr *Tracer) { tr.DisablePrefix("/x"); tr.Leave("/x/a") }, nil},
+		{"refcount", func(tr *Tracer) { tr.SetMode(RefCount); tr.Enter("/a", nil); tr.Enter("/a", nil) }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTracer()
+			var errs []error
+			tr.SetStrict(func(err error) { errs = append(errs, err) })
+			tt.fn(tr)
+			switch {
+			case tt.want == nil && len(errs) > 0:
+				t.Errorf("got errors %v, want none", errs)
+			case tt.want != nil && (len(errs) != 1 || !errors.Is(errs[0], tt.want)):
+				t.Errorf("got errors %v, want one wrapping %v", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisablePrefix(t *testing.T) {
+	tr := NewTracer()
+	tr.Enter("/x/a", nil)
+	tr.DisablePrefix("/x")
+	if tr.Exists("/x/a") {
+		t.Error("DisablePrefix kept the existing entry")
+	}
+	tr.Enter("/x/b", nil)
+	tr.Enter("/y", nil)
+	tr.Leave("/x/b")
+	tr.Update("/x/b", 1)
+	if got, want := ids(tr.List(ById)), []string{"/y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if d := tr.Diagnostics(); d.OrphanLeaves != 0 {
+		t.Errorf("OrphanLeaves = %d, want 0", d.OrphanLeaves)
+	}
+	tr.EnablePrefix("/x")
+	tr.Enter("/x/c", nil)
+	if !tr.Exists("/x/c") {
+		t.Error("EnablePrefix did not enable the prefix")
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	tr := NewTracer()
+	ns := tr.Namespace("/lib")
+	ns.Enter("/a", nil)
+	tr.Enter("/app", nil)
+
+	if got, want := ids(ns.List(ById)), []string{"/lib/a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("namespace List = %v, want %v", got, want)
+	}
+	if got, want := ids(tr.List(ById)), []string{"/app", "/lib/a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("root List = %v, want %v", got, want)
+	}
+	if n := ns.Count(); n != 1 {
+		t.Errorf("namespace Count = %d, want 1", n)
+	}
+	if !tr.Exists("/lib/a") || !ns.Exists("/a") {
+		t.Error("the entry is not found by its full and namespaced ids")
+	}
+
+	ns.Leave("/a")
+	if tr.Exists("/lib/a") {
+		t.Error("namespace Leave did not remove the entry")
+	}
+}
+
+func TestClearIsNotCompletion(t *testing.T) {
+	for _, name := range []string{"root", "namespace"} {
+		t.Run(name, func(t *testing.T) {
+			tr := NewTracer()
+			tr.SetHistorySize(10)
+			tr.SetStatsDepth(1)
+			var left []string
+			tr.OnLeave(func(e Entry, d time.Duration) { left = append(left, e.Id) })
+			tr.Enter("/ns/a", nil)
+			tr.Enter("/other", nil)
+
+			if name == "root" {
+				tr.Clear()
+			} else {
+				tr.Namespace("/ns").Clear()
+				if !tr.Exists("/other") {
+					t.Error("namespace Clear removed an entry outside it")
+				}
+			}
+			if tr.Exists("/ns/a") {
+				t.Error("Clear kept the entry")
+			}
+			if len(left) > 0 {
+				t.Errorf("OnLeave was called for %v", left)
+			}
+			if h := tr.History(0); len(h) > 0 {
+				t.Errorf("History = %v, want none", ids(h))
+			}
+			if s := tr.Stats(""); s.Count != 0 {
+				t.Errorf("Stats Count = %d, want 0", s.Count)
+			}
+		})
+	}
+}
+
+func TestHooks(t *testing.T) {
+	tr := NewTracer()
+	var calls []string
+	tr.OnEnter(func(e Entry) { calls = append(calls, "enter1 "+e.Id) })
+	stop := tr.OnEnter(func(e Entry) { calls = append(calls, "enter2 "+e.Id) })
+	tr.Namespace("/ns").OnLeave(func(e Entry, d time.Duration) {
+		calls = append(calls, "leave "+e.Id)
+		// Hooks are called unlocked, so they may use the Tracer.
+		tr.Count()
+	})
+	tr.OnEnter(func(e Entry) { panic("hook") })
+	var errs []error
+	tr.SetStrict(func(err error) { errs = append(errs, err) })
+
+	tr.Enter("/ns/a", nil)
+	tr.Leave("/ns/a")
+	stop()
+	tr.Enter("/b", nil)
+	tr.Leave("/b")
+
+	want := []string{"enter1 /ns/a", "enter2 /ns/a", "leave /ns/a", "enter1 /b"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+	if len(errs) != 2 {
+		t.Errorf("got %d errors from the panicking hook, want 2", len(errs))
+	}
+}
+
+func TestUpdateTouchRename(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.Enter("/a", 1)
+	clock.Add(time.Second)
+	tr.Update("/a", 2)
+	e, _ := tr.Get("/a")
+	if e.Props != 2 || !e.Time.Equal(clock.Now().Add(-time.Second)) {
+		t.Errorf("after Update got %v at %v", e.Props, e.Time)
+	}
+	tr.Touch("/a")
+	e, _ = tr.Get("/a")
+	if !e.Time.Equal(clock.Now()) {
+		t.Errorf("after Touch Time = %v, want %v", e.Time, clock.Now())
+	}
+
+	tr.Enter("/c", nil)
+	if err := tr.Rename("/a", "/c"); !errors.Is(err, ErrExists) {
+		t.Errorf("Rename to an existing id returned %v", err)
+	}
+	if err := tr.Rename("/missing", "/d"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Rename of a missing id returned %v", err)
+	}
+	if err := tr.Rename("/a", "/b"); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := tr.Get("/b"); !ok || e.Props != 2 || tr.Exists("/a") {
+		t.Errorf("after Rename got %v, %v", e, ok)
+	}
+}
+
+func TestLeavePrefix(t *testing.T) {
+	tr := NewTracer()
+	tr.SetHistorySize(10)
+	for _, id := range []string{"/conn/1", "/conn/2", "/connx", "/other"} {
+		tr.Enter(id, nil)
+	}
+	if n := tr.LeavePrefix("/conn"); n != 2 {
+		t.Errorf("LeavePrefix returned %d, want 2", n)
+	}
+	if got, want := ids(tr.List(ById)), []string{"/connx", "/other"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if h := tr.History(0); len(h) != 2 {
+		t.Errorf("History has %d entries, want 2", len(h))
+	}
+}
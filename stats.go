@@ -0,0 +1,160 @@
+package statetrc
+
+import "time"
+
+// DurationStats summarizes the durations of completed entries, that is the times between
+// entering and leaving their states.
+type DurationStats struct {
+	// Count is the number of completed entries
+	Count uint64
+	// Min and Max are the shortest and longest durations
+	Min, Max time.Duration
+	// Mean is the average duration
+	Mean time.Duration
+	// Last is the duration of the most recently completed entry
+	Last time.Duration
+	// P50, P95 and P99 are estimates of percentiles of the durations. They are only set if
+	// histograms are enabled with SetHistogramBuckets.
+	P50, P95, P99 time.Duration
+}
+
+// durationStats accumulates DurationStats.
+type durationStats struct {
+	DurationStats
+	total time.Duration
+	// lastEnd is the EndTime of the most recently completed entry
+	lastEnd time.Time
+	// since is the EndTime of the first completed entry
+	since time.Time
+	hist  DurationHistogram
+}
+
+// add records the duration d of an entry that completed at end. If there are bucket bounds
+// it is also counted in the histogram.
+func (s *durationStats) add(d time.Duration, end time.Time, bounds []time.Duration) {
+	if s.Count == 0 || d < s.Min {
+		s.Min = d
+	}
+	if d > s.Max {
+		s.Max = d
+	}
+	s.Count++
+	s.total += d
+	s.Mean = s.total / time.Duration(s.Count)
+	s.Last, s.lastEnd = d, end
+	if len(bounds) > 0 {
+		s.hist.add(d, bounds)
+	}
+}
+
+func (s *durationStats) merge(o *durationStats) {
+	if o.Count == 0 {
+		return
+	}
+	if s.Count == 0 || o.Min < s.Min {
+		s.Min = o.Min
+	}
+	if o.Max > s.Max {
+		s.Max = o.Max
+	}
+	s.Count += o.Count
+	s.total += o.total
+	s.Mean = s.total / time.Duration(s.Count)
+	if !o.lastEnd.Before(s.lastEnd) {
+		s.Last, s.lastEnd = o.Last, o.lastEnd
+	}
+	s.hist.merge(&o.hist)
+}
+
+// result returns the DurationStats with the percentiles set from the histogram.
+func (s *durationStats) result() DurationStats {
+	r := s.DurationStats
+	if s.hist.Count > 0 {
+		r.P50, r.P95, r.P99 = s.hist.Quantile(0.5), s.hist.Quantile(0.95), s.hist.Quantile(0.99)
+	}
+	return r
+}
+
+// SetStatsDepth enables keeping DurationStats for completed entries, grouped by the first depth
+// elements of their ids as by Aggregate, so that you can see how long a kind of state usually
+// takes. A negative depth keeps statistics for each id, which is only advisable if the number
+// of distinct ids is small. Passing zero disables the statistics. Changing the depth discards
+// the statistics collected so far.
+func (t *Tracer) SetStatsDepth(depth int) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.statsDepth = depth
+	t.stats = nil
+	if depth != 0 {
+		t.stats = map[string]*durationStats{}
+	}
+}
+
+// SetStatsDepth calls SetStatsDepth on the default Tracer.
+func SetStatsDepth(depth int) {
+	std.SetStatsDepth(depth)
+}
+
+// Stats returns the statistics of the completed entries whose groups, as set by SetStatsDepth,
+// are under the path prefix. For example at depth 2 Stats("/conn") combines the groups
+// "/conn/read" and "/conn/write". See CountPrefix for how prefixes are matched.
+func (t *Tracer) Stats(prefix string) DurationStats {
+	prefix = t.full(prefix)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireStatsLocked()
+	var res durationStats
+	for key, s := range t.stats {
+		if hasPathPrefix(key, prefix) {
+			res.merge(s)
+		}
+	}
+	return res.result()
+}
+
+// Stats calls Stats on the default Tracer.
+func Stats(prefix string) DurationStats {
+	return std.Stats(prefix)
+}
+
+// AllStats returns the statistics of each group of completed entries, as set by SetStatsDepth,
+// keyed by the prefix of the group.
+func (t *Tracer) AllStats() map[string]DurationStats {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireStatsLocked()
+	res := map[string]DurationStats{}
+	for key, s := range t.stats {
+		if t.inScope(key) {
+			res[key] = s.result()
+		}
+	}
+	return res
+}
+
+// AllStats calls AllStats on the default Tracer.
+func AllStats() map[string]DurationStats {
+	return std.AllStats()
+}
+
+// statsKeyLocked returns the key under which the statistics for id are kept. t.mtx must be held.
+func (c *core) statsKeyLocked(id string) string {
+	if c.statsDepth < 0 {
+		return id
+	}
+	return idPrefix(id, c.statsDepth)
+}
+
+// recordStatsLocked adds the duration of the completed entry e to the statistics. t.mtx must be held.
+func (c *core) recordStatsLocked(e Entry) {
+	if c.stats == nil {
+		return
+	}
+	key := c.statsKeyLocked(e.Id)
+	s, ok := c.stats[key]
+	if !ok || c.statsExpiredLocked(key, s, e.EndTime) {
+		s = &durationStats{since: e.EndTime}
+		c.stats[key] = s
+	}
+	s.add(e.EndTime.Sub(e.Time), e.EndTime, c.buckets)
+}
@@ -0,0 +1,84 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"testing"
+	"time"
+)
+
+// completeAfter enters id and leaves it d later.
+func completeAfter(tr *Tracer, clock *testClock, id string, d time.Duration) {
+	tr.Enter(id, nil)
+	clock.Add(d)
+	tr.Leave(id)
+}
+
+func TestStats(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.SetStatsDepth(2)
+	completeAfter(tr, clock, "/conn/read/1", time.Second)
+	completeAfter(tr, clock, "/conn/write/1", 3*time.Second)
+	completeAfter(tr, clock, "/job/1", 10*time.Second)
+
+	tests := []struct {
+		prefix string
+		want   DurationStats
+	}{
+		{"/conn", DurationStats{Count: 2, Min: time.Second, Max: 3 * time.Second, Mean: 2 * time.Second, Last: 3 * time.Second}},
+		{"/conn/read", DurationStats{Count: 1, Min: time.Second, Max: time.Second, Mean: time.Second, Last: time.Second}},
+		{"/job", DurationStats{Count: 1, Min: 10 * time.Second, Max: 10 * time.Second, Mean: 10 * time.Second, Last: 10 * time.Second}},
+		{"/none", DurationStats{}},
+	}
+	for _, tt := range tests {
+		if got := tr.Stats(tt.prefix); got != tt.want {
+			t.Errorf("Stats(%q) = %+v, want %+v", tt.prefix, got, tt.want)
+		}
+	}
+	if n := len(tr.AllStats()); n != 3 {
+		t.Errorf("AllStats has %d groups, want 3", n)
+	}
+
+	tr.ResetStats()
+	if s := tr.Stats(""); s.Count != 0 {
+		t.Errorf("after ResetStats Count = %d", s.Count)
+	}
+}
+
+func TestStatsPercentiles(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.SetStatsDepth(1)
+	tr.SetHistogramBuckets(time.Second, 2*time.Second, 4*time.Second)
+	for i := 0; i < 100; i++ {
+		d := 500 * time.Millisecond
+		if i >= 90 {
+			d = 3 * time.Second
+		}
+		completeAfter(tr, clock, "/a", d)
+	}
+
+	s := tr.Stats("/a")
+	if s.P50 > time.Second {
+		t.Errorf("P50 = %v, want at most 1s", s.P50)
+	}
+	if s.P99 <= 2*time.Second || s.P99 > 4*time.Second {
+		t.Errorf("P99 = %v, want between 2s and 4s", s.P99)
+	}
+	if h := tr.Histogram("/a"); h.Count != 100 {
+		t.Errorf("histogram Count = %d, want 100", h.Count)
+	}
+}
+
+func TestStatsResetInterval(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.SetStatsDepth(1)
+	tr.SetStatsResetInterval(time.Minute)
+	completeAfter(tr, clock, "/a", time.Second)
+	if s := tr.Stats("/a"); s.Count != 1 {
+		t.Fatalf("Count = %d, want 1", s.Count)
+	}
+	clock.Add(2 * time.Minute)
+	if s := tr.Stats("/a"); s.Count != 0 {
+		t.Errorf("after the interval Count = %d, want 0", s.Count)
+	}
+}
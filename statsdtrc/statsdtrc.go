@@ -0,0 +1,202 @@
+// Package statsdtrc periodically pushes metrics about the entries of a statetrc Tracer to a
+// StatsD or DogStatsD server over UDP:
+//
+//	e, err := statsdtrc.Start(statetrc.Default(), "127.0.0.1:8125", statsdtrc.Options{Depth: 1})
+//	if err != nil {
+//		...
+//	}
+//	defer e.Stop()
+//
+// For each prefix of the entries two gauges are sent: the number of entries and the age of the
+// oldest entry in milliseconds. With plain StatsD the prefix is part of the metric name, as in
+// statetrc.active.conn, and with DogStatsD it is sent as a tag, as in statetrc.active with the
+// tag prefix:/conn.
+//
+// If statetrc.Tracer.SetRateWindow enabled tracking of rates, the gauges enter_rate and
+// leave_rate are also sent for each of the prefixes it uses, with the numbers of entries entered
+// and left per second.
+package statsdtrc
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeffwilliams/statetrc"
+)
+
+// maxPacket is the largest UDP payload sent, chosen to fit in an Ethernet frame.
+const maxPacket = 1432
+
+// Options configures an Exporter.
+type Options struct {
+	// Prefix is prepended to the metric names. Empty means "statetrc".
+	Prefix string
+	// Depth is the number of elements of the entry ids used to group them, as for
+	// statetrc.Tracer.Aggregate. Zero means 1.
+	Depth int
+	// Interval is the time between pushes. Zero means 10 seconds.
+	Interval time.Duration
+	// DogStatsD sends the prefixes of the entries as tags rather than in the metric names.
+	DogStatsD bool
+	// Tags are extra DogStatsD tags such as "service:api" added to every metric.
+	Tags []string
+}
+
+// Exporter pushes metrics until it is stopped.
+type Exporter struct {
+	t    *statetrc.Tracer
+	conn net.Conn
+	opts Options
+	// seen and rates hold the prefixes sent in the last push, so that gauges of prefixes that
+	// no longer have entries or calls can be reset to zero.
+	seen     map[string]bool
+	rates    map[string]bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Start starts pushing metrics about the entries of t to the server at the UDP address addr.
+// If t is nil the default Tracer is used.
+func Start(t *statetrc.Tracer, addr string, opts Options) (*Exporter, error) {
+	if t == nil {
+		t = statetrc.Default()
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "statetrc"
+	}
+	if opts.Depth <= 0 {
+		opts.Depth = 1
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Exporter{
+		t:    t,
+		conn: conn,
+		opts: opts,
+		seen: map[string]bool{},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+// Stop stops pushing metrics and closes the connection.
+func (e *Exporter) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.stop)
+		<-e.done
+		e.conn.Close()
+	})
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.opts.Interval)
+	defer ticker.Stop()
+	for {
+		e.push()
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// push sends the current metrics. Errors are ignored, as is usual for StatsD, since the
+// server may not be running.
+func (e *Exporter) push() {
+	var buf bytes.Buffer
+	send := func(line string) {
+		if buf.Len() > 0 && buf.Len()+1+len(line) > maxPacket {
+			e.conn.Write(buf.Bytes())
+			buf.Reset()
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line)
+	}
+
+	seen := map[string]bool{}
+	for _, s := range e.t.Aggregate(e.opts.Depth) {
+		seen[s.Prefix] = true
+		send(e.gauge("active", s.Prefix, float64(s.Count)))
+		send(e.gauge("oldest_age_ms", s.Prefix, float64(s.Oldest.Milliseconds())))
+	}
+	for p := range e.seen {
+		if !seen[p] {
+			send(e.gauge("active", p, 0))
+			send(e.gauge("oldest_age_ms", p, 0))
+		}
+	}
+	e.seen = seen
+
+	rates := map[string]bool{}
+	for _, r := range e.t.Rates() {
+		rates[r.Prefix] = true
+		send(e.gauge("enter_rate", r.Prefix, r.Enters))
+		send(e.gauge("leave_rate", r.Prefix, r.Leaves))
+	}
+	for p := range e.rates {
+		if !rates[p] {
+			send(e.gauge("enter_rate", p, 0))
+			send(e.gauge("leave_rate", p, 0))
+		}
+	}
+	e.rates = rates
+
+	if buf.Len() > 0 {
+		e.conn.Write(buf.Bytes())
+	}
+}
+
+// gauge formats a gauge named name for the entries under prefix.
+func (e *Exporter) gauge(name, prefix string, v float64) string {
+	if !e.opts.DogStatsD {
+		return fmt.Sprintf("%s.%s.%s:%g|g", e.opts.Prefix, name, metricName(prefix), v)
+	}
+	tags := append([]string{"prefix:" + tagValue(prefix)}, e.opts.Tags...)
+	return fmt.Sprintf("%s.%s:%g|g|#%s", e.opts.Prefix, name, v, strings.Join(tags, ","))
+}
+
+// metricName converts the id prefix p to metric name elements, as in "conn.read" for
+// "/conn/read".
+func metricName(p string) string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return "root"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '/':
+			return '.'
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, p)
+}
+
+// tagValue removes the characters that have a meaning in DogStatsD lines from v.
+func tagValue(v string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ',', '|', '#', '\n':
+			return '_'
+		}
+		return r
+	}, v)
+}
@@ -0,0 +1,206 @@
+//go:build !statetrc_off
+
+package statsdtrc
+
+import (
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffwilliams/statetrc"
+)
+
+func TestMetricName(t *testing.T) {
+	tests := []struct {
+		prefix, want string
+	}{
+		{"/conn", "conn"},
+		{"/conn/read", "conn.read"},
+		{"/", "root"},
+		{"", "root"},
+		{"/a b/c:d|e", "a_b.c_d_e"},
+		{"/x-y_z/9", "x-y_z.9"},
+	}
+	for _, tt := range tests {
+		if got := metricName(tt.prefix); got != tt.want {
+			t.Errorf("metricName(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestTagValue(t *testing.T) {
+	tests := []struct {
+		v, want string
+	}{
+		{"/conn", "/conn"},
+		{"/a,b|c#d\ne", "/a_b_c_d_e"},
+	}
+	for _, tt := range tests {
+		if got := tagValue(tt.v); got != tt.want {
+			t.Errorf("tagValue(%q) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+// newTestExporter returns an Exporter for t that sends to a local socket, which it also
+// returns, without starting to push.
+func newTestExporter(t *testing.T, tr *statetrc.Tracer, opts Options) (*Exporter, net.PacketConn) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if opts.Prefix == "" {
+		opts.Prefix = "statetrc"
+	}
+	if opts.Depth == 0 {
+		opts.Depth = 1
+	}
+	return &Exporter{t: tr, conn: conn, opts: opts, seen: map[string]bool{}}, pc
+}
+
+// readLines returns the lines of the packets received on pc until none arrives for a while,
+// and checks that no packet is larger than maxPacket.
+func readLines(t *testing.T, pc net.PacketConn) (lines []string, packets int) {
+	buf := make([]byte, 64<<10)
+	for {
+		pc.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			sort.Strings(lines)
+			return lines, packets
+		}
+		if n > maxPacket {
+			t.Errorf("packet of %d bytes, want at most %d", n, maxPacket)
+		}
+		packets++
+		lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+	}
+}
+
+func TestPush(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"statsd", Options{}, []string{
+			"statetrc.active.conn:2|g",
+			"statetrc.active.job:1|g",
+			"statetrc.oldest_age_ms.conn:0|g",
+			"statetrc.oldest_age_ms.job:0|g",
+		}},
+		{"dogstatsd", Options{Prefix: "app", DogStatsD: true, Tags: []string{"service:api"}}, []string{
+			"app.active:1|g|#prefix:/job,service:api",
+			"app.active:2|g|#prefix:/conn,service:api",
+			"app.oldest_age_ms:0|g|#prefix:/conn,service:api",
+			"app.oldest_age_ms:0|g|#prefix:/job,service:api",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := statetrc.NewTracer()
+			tr.SetClock(func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC) })
+			tr.Enter("/conn/1", nil)
+			tr.Enter("/conn/2", nil)
+			tr.Enter("/job", nil)
+			e, pc := newTestExporter(t, tr, tt.opts)
+			e.push()
+			if got, _ := readLines(t, pc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushResetsMissingPrefixes(t *testing.T) {
+	tr := statetrc.NewTracer()
+	tr.SetClock(func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC) })
+	tr.Enter("/conn/1", nil)
+	tr.Enter("/job", nil)
+	e, pc := newTestExporter(t, tr, Options{})
+	e.push()
+	readLines(t, pc)
+
+	// The gauges of a prefix without entries are set to zero once, and then no longer sent.
+	tr.Leave("/conn/1")
+	e.push()
+	want := []string{
+		"statetrc.active.conn:0|g",
+		"statetrc.active.job:1|g",
+		"statetrc.oldest_age_ms.conn:0|g",
+		"statetrc.oldest_age_ms.job:0|g",
+	}
+	if got, _ := readLines(t, pc); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	e.push()
+	want = []string{"statetrc.active.job:1|g", "statetrc.oldest_age_ms.job:0|g"}
+	if got, _ := readLines(t, pc); !reflect.DeepEqual(got, want) {
+		t.Errorf("after another push got %q, want %q", got, want)
+	}
+}
+
+func TestPushSplitsPackets(t *testing.T) {
+	tr := statetrc.NewTracer()
+	for i := 0; i < 100; i++ {
+		tr.EnterUnique("/"+strings.Repeat("x", 20), nil)
+		tr.Enter("/prefix"+strings.Repeat("y", i%50)+string(rune('a'+i/50)), nil)
+	}
+	e, pc := newTestExporter(t, tr, Options{})
+	e.push()
+	lines, packets := readLines(t, pc)
+	if packets < 2 {
+		t.Errorf("got %d packets, want the metrics split", packets)
+	}
+	if n := len(lines); n != 2*101 {
+		t.Errorf("got %d lines, want %d", n, 2*101)
+	}
+}
+
+func TestPushRates(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tr := statetrc.NewTracer()
+	tr.SetClock(func() time.Time { return now })
+	tr.SetRateWindow(1, 10*time.Second)
+	tr.Enter("/a", nil)
+	tr.Enter("/b", nil)
+	tr.Leave("/b")
+	now = now.Add(2 * time.Second)
+	e, pc := newTestExporter(t, tr, Options{})
+	e.push()
+	want := []string{
+		"statetrc.active.a:1|g",
+		"statetrc.enter_rate.a:0.5|g",
+		"statetrc.enter_rate.b:0.5|g",
+		"statetrc.leave_rate.a:0|g",
+		"statetrc.leave_rate.b:0.5|g",
+		"statetrc.oldest_age_ms.a:2000|g",
+	}
+	if got, _ := readLines(t, pc); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// The rates of prefixes without calls in the window are set to zero once.
+	now = now.Add(time.Minute)
+	e.push()
+	want = []string{
+		"statetrc.active.a:1|g",
+		"statetrc.enter_rate.a:0|g",
+		"statetrc.enter_rate.b:0|g",
+		"statetrc.leave_rate.a:0|g",
+		"statetrc.leave_rate.b:0|g",
+		"statetrc.oldest_age_ms.a:62000|g",
+	}
+	if got, _ := readLines(t, pc); !reflect.DeepEqual(got, want) {
+		t.Errorf("after the window got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,179 @@
+package statetrc
+
+import (
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// statusPage is the template for StatusHandler.
+var statusPage = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>statetrc status</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; vertical-align: top; }
+th { background: #eee; }
+td.num { text-align: right; }
+pre { margin: 0; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>statetrc status</h1>
+<p>At {{.At.Format "2006-01-02 15:04:05.000 MST"}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Active entries</th><td class="num">{{.Total}}</td></tr>
+<tr><th>Enters</th><td class="num">{{.Diag.Enters}}</td></tr>
+<tr><th>Leaves</th><td class="num">{{.Diag.Leaves}}</td></tr>
+<tr><th>Enter rate</th><td class="num">{{printf "%.2f" .EnterRate}}/s</td></tr>
+<tr><th>Leave rate</th><td class="num">{{printf "%.2f" .LeaveRate}}/s</td></tr>
+<tr><th>Overwrites</th><td class="num">{{.Diag.Overwrites}}</td></tr>
+<tr><th>Orphan leaves</th><td class="num">{{.Diag.OrphanLeaves}}</td></tr>
+</table>
+<p>Rates are averaged over the last {{.RateWindow}}{{if not .Tracked}}, since the page was previously viewed{{end}}.</p>
+{{- if .Namespace}}
+<p>The counts of calls{{if not .Tracked}} and the rates{{end}} are those of the whole Tracer, including entries outside {{.Namespace}}.</p>
+{{- end}}
+
+<h2>Prefixes</h2>
+<table>
+<tr><th>Prefix</th><th>Count</th><th>Oldest</th><th>Newest</th></tr>
+{{- range .Prefixes}}
+<tr><td>{{.Prefix}}</td><td class="num">{{.Count}}</td><td class="num">{{.Oldest}}</td><td class="num">{{.Newest}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Oldest entries</h2>
+<table>
+<tr><th>Id</th><th>Age</th><th>Count</th><th>Props</th></tr>
+{{- range .Oldest}}
+<tr><td>{{.Id}}</td><td class="num">{{.Age}}</td><td class="num">{{.Count}}</td><td><pre>{{.Props}}</pre></td></tr>
+{{- end}}
+</table>
+
+<h2>Recent completions</h2>
+{{- if .HistoryOff}}
+<p>Completed entries are not recorded. Enable recording with SetHistorySize.</p>
+{{- else}}
+<table>
+<tr><th>Id</th><th>Duration</th><th>Ended</th><th>Props</th></tr>
+{{- range .Completed}}
+<tr><td>{{.Id}}</td><td class="num">{{.Age}}</td><td>{{.Ended}}</td><td><pre>{{.Props}}</pre></td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+type statusPrefix struct {
+	Prefix         string
+	Count          int
+	Oldest, Newest string
+}
+
+type statusEntry struct {
+	Id    string
+	Age   string
+	Count int
+	Ended string
+	Props string
+}
+
+// statusRows is the number of entries shown in each of the entry tables of StatusHandler.
+const statusRows = 20
+
+// StatusHandler returns an http.Handler that serves an HTML status page in the manner of
+// OpenCensus zPages, summarizing what the process is doing right now: the number of entries,
+// the counts under each top-level prefix, the oldest entries, the most recently completed
+// entries with their durations if SetHistorySize enabled recording them, and the rates of
+// Enter and Leave. The rates are those tracked by SetRateWindow if it was called, and are
+// otherwise averaged since the page was last served by the same handler. For a namespace the
+// page shows the entries under its prefix, but the counts of calls of DiagnosticCounters, and
+// the rates if they are not tracked, are those of the whole Tracer, as the page says.
+func (t *Tracer) StatusHandler() http.Handler {
+	var mtx sync.Mutex
+	last := t.Now()
+	lastDiag := t.Diagnostics()
+
+	return t.authorize(func(w http.ResponseWriter, r *http.Request) {
+		o := &DefaultFormatOptions
+		s := t.snapshot(nil, ByAge)
+		diag := t.Diagnostics()
+		completed := t.completed()
+
+		mtx.Lock()
+		window := s.At.Sub(last)
+		enters, leaves := diag.Enters-lastDiag.Enters, diag.Leaves-lastDiag.Leaves
+		last, lastDiag = s.At, diag
+		mtx.Unlock()
+
+		data := struct {
+			At                   time.Time
+			Total                int
+			Diag                 DiagnosticCounters
+			EnterRate, LeaveRate float64
+			RateWindow           string
+			Tracked              bool
+			Namespace            string
+			Prefixes             []statusPrefix
+			Oldest, Completed    []statusEntry
+			HistoryOff           bool
+		}{
+			At:         s.At,
+			Total:      len(s.Entries),
+			Diag:       diag,
+			RateWindow: o.duration(window),
+			Namespace:  t.prefix,
+			HistoryOff: !t.historyEnabled(),
+		}
+		if er, lr, w := t.totalRates(); w > 0 {
+			data.EnterRate, data.LeaveRate = er, lr
+			data.RateWindow, data.Tracked = o.duration(w), true
+		} else if secs := window.Seconds(); secs > 0 {
+			data.EnterRate = float64(enters) / secs
+			data.LeaveRate = float64(leaves) / secs
+		}
+
+		for _, p := range s.Aggregate(1) {
+			data.Prefixes = append(data.Prefixes, statusPrefix{
+				Prefix: p.Prefix,
+				Count:  p.Count,
+				Oldest: o.duration(p.Oldest),
+				Newest: o.duration(p.Newest),
+			})
+		}
+		for _, e := range page(s.Entries, 0, statusRows) {
+			data.Oldest = append(data.Oldest, statusEntry{
+				Id:    e.Id,
+				Age:   o.duration(e.Age(s.At)),
+				Count: e.Count,
+				Props: propsString(e, o),
+			})
+		}
+		for i := len(completed) - 1; i >= 0 && len(data.Completed) < statusRows; i-- {
+			e := completed[i]
+			data.Completed = append(data.Completed, statusEntry{
+				Id:    e.Id,
+				Age:   o.duration(e.EndTime.Sub(e.Time)),
+				Ended: o.start(e.EndTime),
+				Props: propsString(e, o),
+			})
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		statusPage.Execute(w, data)
+	})
+}
+
+// StatusHandler calls StatusHandler on the default Tracer.
+func StatusHandler() http.Handler {
+	return std.StatusHandler()
+}
@@ -0,0 +1,74 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var statusRate = regexp.MustCompile(`Enter rate</th><td class="num">([0-9.]+)/s`)
+
+func TestStatusHandler(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.SetHistorySize(10)
+	h := tr.StatusHandler()
+	for i := 0; i < 10; i++ {
+		tr.Enter("/conn/1", nil)
+		tr.Leave("/conn/1")
+	}
+	tr.Enter("/job", nil)
+	clock.Add(10 * time.Second)
+
+	body := get(h, "/").Body.String()
+	for _, want := range []string{"/job", "/conn/1", "Recent completions"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+	// The rates are averaged since the handler was created.
+	if m := statusRate.FindStringSubmatch(body); m == nil || m[1] != "1.10" {
+		t.Errorf("enter rate %v, want 1.10", m)
+	}
+
+	clock.Add(10 * time.Second)
+	body = get(h, "/").Body.String()
+	if m := statusRate.FindStringSubmatch(body); m == nil || m[1] != "0.00" {
+		t.Errorf("enter rate %v since the previous request, want 0.00", m)
+	}
+}
+
+func TestStatusHandlerTrackedRates(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.SetRateWindow(1, 10*time.Second)
+	for i := 0; i < 9; i++ {
+		tr.Enter("/a", nil)
+	}
+	clock.Add(time.Second)
+	body := get(tr.StatusHandler(), "/").Body.String()
+	// The rates are averaged over the time since SetRateWindow until a window has passed.
+	if m := statusRate.FindStringSubmatch(body); m == nil || m[1] != "9.00" {
+		t.Errorf("enter rate %v, want 9.00", m)
+	}
+	if !strings.Contains(body, "averaged over the last 1s.") {
+		t.Error("page does not report the rate window")
+	}
+}
+
+func TestStatusHandlerNamespace(t *testing.T) {
+	tr := NewTracer()
+	tr.Enter("/a/1", nil)
+	tr.Enter("/b/1", nil)
+	body := get(tr.Namespace("/a").StatusHandler(), "/").Body.String()
+	if strings.Contains(body, "/b/1") {
+		t.Error("page shows an entry outside the namespace")
+	}
+	if !strings.Contains(body, "those of the whole Tracer, including entries outside /a.") {
+		t.Error("page does not say that the counters are not scoped")
+	}
+	if body = get(tr.StatusHandler(), "/").Body.String(); strings.Contains(body, "whole Tracer") {
+		t.Error("page of the root Tracer says that the counters are not scoped")
+	}
+}
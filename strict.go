@@ -0,0 +1,27 @@
+package statetrc
+
+// SetStrict enables strict mode, in which misuse of the Tracer is reported by calling fn.
+// Leaving an id that does not exist is reported with an error wrapping ErrNotFound, and
+// entering an id that already exists in Overwrite mode with an error wrapping ErrExists.
+// This catches instrumentation bugs that are otherwise silently ignored. fn is called
+// without the Tracer locked. Passing nil disables strict mode.
+func (t *Tracer) SetStrict(fn func(err error)) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.misuse = fn
+}
+
+// SetStrict calls SetStrict on the default Tracer.
+func SetStrict(fn func(err error)) {
+	std.SetStrict(fn)
+}
+
+// unlockAndReport unlocks t.mtx as unlock does and then passes errs to the misuse function.
+func (t *Tracer) unlockAndReport(errs []error) {
+	misuse := t.misuse
+	t.unlock()
+
+	for _, err := range errs {
+		misuse(err)
+	}
+}
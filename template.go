@@ -0,0 +1,78 @@
+package statetrc
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"text/template"
+	"time"
+)
+
+// templateFuncs returns the functions available to templates used with snapshot s.
+func templateFuncs(s Snapshot) template.FuncMap {
+	return template.FuncMap{
+		// age returns the age of an entry relative to the capture time
+		"age": func(e Entry) time.Duration {
+			return e.Age(s.At)
+		},
+		// duration renders a duration using DefaultFormatOptions
+		"duration": DefaultFormatOptions.duration,
+		// truncate shortens the string form of v to at most n characters
+		"truncate": func(n int, v interface{}) string {
+			return truncate(fmt.Sprint(v), n)
+		},
+		// basename returns the last element of an id
+		"basename": func(id string) string {
+			return path.Base(id)
+		},
+		// props returns the labels and Props of an entry on one line
+		"props": func(e Entry) string {
+			return propsString(e, &DefaultFormatOptions)
+		},
+	}
+}
+
+// TemplateFormatter writes snapshots using a text/template. The template is executed with
+// the Snapshot as its data and has these functions available in addition to the standard ones:
+//
+//	age ENTRY         the age of the entry relative to the capture time
+//	duration D        D rendered according to DefaultFormatOptions
+//	truncate N VALUE  the string form of VALUE shortened to at most N characters
+//	basename ID       the last element of ID
+//	props ENTRY       the labels and Props of the entry on one line
+//
+// For example:
+//
+//	{{range .Entries}}{{.Id | truncate 40}} {{age .}}
+//	{{end}}
+type TemplateFormatter struct {
+	tmpl *template.Template
+}
+
+// NewTemplateFormatter parses text as a template for a TemplateFormatter.
+func NewTemplateFormatter(text string) (*TemplateFormatter, error) {
+	t, err := template.New("statetrc").Funcs(templateFuncs(Snapshot{})).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateFormatter{tmpl: t}, nil
+}
+
+// Format implements Formatter.
+func (f *TemplateFormatter) Format(w io.Writer, s Snapshot) error {
+	t, err := f.tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	return t.Funcs(templateFuncs(s)).Execute(w, s)
+}
+
+// FormatTemplate writes the Snapshot to w using the template text. See TemplateFormatter for
+// the functions available to the template.
+func FormatTemplate(w io.Writer, text string, s Snapshot) error {
+	f, err := NewTemplateFormatter(text)
+	if err != nil {
+		return err
+	}
+	return f.Format(w, s)
+}
@@ -0,0 +1,102 @@
+package statetrc
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Node is a node in the tree formed by the elements of entry ids. For example the ids "/conn/1"
+// and "/conn/2" form a node "conn" with the children "1" and "2".
+type Node struct {
+	// Last element of the node's path. It is empty for the root.
+	Name string
+	// Path of the node, which is the id of the entries it and its children represent.
+	Path string
+	// Entry whose id is Path, if there is one
+	Entry *Entry
+	// Number of entries in the subtree rooted at the node
+	Count int
+	// Age of the oldest entry in the subtree rooted at the node
+	MaxAge time.Duration
+	// Children ordered by Name
+	Children []*Node
+}
+
+// Tree returns the entries of the Snapshot arranged into a tree by the elements of their ids.
+// Ages are relative to the time the Snapshot was captured.
+func (s Snapshot) Tree() *Node {
+	root := &Node{}
+	nodes := map[string]*Node{}
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		age := e.Age(s.At)
+
+		n := root
+		n.add(age)
+		parts := strings.Split(e.Id, "/")
+		for j, name := range parts {
+			if name == "" {
+				continue
+			}
+			path := strings.Join(parts[:j+1], "/")
+			c, ok := nodes[path]
+			if !ok {
+				c = &Node{Name: name, Path: path}
+				nodes[path] = c
+				n.Children = append(n.Children, c)
+			}
+			n = c
+			n.add(age)
+		}
+		n.Entry = e
+	}
+	root.sort()
+	return root
+}
+
+// Tree returns a tree of the currently existing entries. See Snapshot.Tree.
+func (t *Tracer) Tree() *Node {
+	return t.Capture(ById).Tree()
+}
+
+// Tree calls Tree on the default Tracer.
+func Tree() *Node {
+	return std.Tree()
+}
+
+func (n *Node) add(age time.Duration) {
+	n.Count++
+	if age > n.MaxAge {
+		n.MaxAge = age
+	}
+}
+
+func (n *Node) sort() {
+	sort.Slice(n.Children, func(i, j int) bool {
+		return n.Children[i].Name < n.Children[j].Name
+	})
+	for _, c := range n.Children {
+		c.sort()
+	}
+}
+
+// String renders the tree below n with each node on its own line, indented by two
+// spaces per level, along with the number of entries and the oldest age in its subtree.
+func (n *Node) String() string {
+	var buf bytes.Buffer
+	for _, c := range n.Children {
+		c.write(&buf, 0)
+	}
+	return buf.String()
+}
+
+func (n *Node) write(buf *bytes.Buffer, depth int) {
+	buf.WriteString(strings.Repeat("  ", depth))
+	fmt.Fprintf(buf, "%s (%d, oldest %s)\n", n.Name, n.Count, DefaultFormatOptions.duration(n.MaxAge))
+	for _, c := range n.Children {
+		c.write(buf, depth+1)
+	}
+}
@@ -0,0 +1,40 @@
+package statetrc
+
+// TypedTracer provides access to the entries of a Tracer whose properties have type T,
+// so that they can be attached and retrieved without type assertions. Create one with Typed.
+type TypedTracer[T any] struct {
+	t *Tracer
+}
+
+// Typed returns a TypedTracer for the entries of t with properties of type T.
+func Typed[T any](t *Tracer) TypedTracer[T] {
+	return TypedTracer[T]{t: t}
+}
+
+// Enter calls Enter on the underlying Tracer with props of type T.
+func (tt TypedTracer[T]) Enter(id string, props T) *Region {
+	return tt.t.Enter(id, props)
+}
+
+// Get returns the properties of the entry with the specified id. It returns false if there
+// is no such entry or its properties are not of type T.
+func (tt TypedTracer[T]) Get(id string) (T, bool) {
+	var props T
+	e, ok := tt.t.Get(id)
+	if !ok {
+		return props, false
+	}
+	props, ok = e.Props.(T)
+	return props, ok
+}
+
+// EnterT calls Enter on the default Tracer with props of type T.
+func EnterT[T any](id string, props T) *Region {
+	return Typed[T](std).Enter(id, props)
+}
+
+// GetT returns the properties of type T of the entry with the specified id in the default Tracer.
+// It returns false if there is no such entry or its properties are not of type T.
+func GetT[T any](id string) (T, bool) {
+	return Typed[T](std).Get(id)
+}
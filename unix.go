@@ -0,0 +1,62 @@
+package statetrc
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// ListenAndServeUnix serves the entries over HTTP on a Unix domain socket at path, for tools
+// that inspect processes which expose no TCP debug port, such as:
+//
+//	curl --unix-socket /run/app/statetrc.sock 'http://localhost/?format=json'
+//
+// The paths are / for Handler, /api for APIHandler, /history for HistoryHandler, /anomalies
+// for AnomalyHandler and /events for SSEHandler. A stale socket at path is removed first. The
+// socket is created in a private directory and moved to path once only its owner may access
+// it, so other users cannot connect in between. Like http.ListenAndServe it only returns on
+// error.
+func (t *Tracer) ListenAndServeUnix(path string) error {
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".statetrc-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "sock")
+
+	l, err := net.Listen("unix", tmp)
+	if err != nil {
+		return err
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	defer l.Close()
+	if err := os.Chmod(tmp, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	defer os.Remove(path)
+	os.Remove(dir)
+	return http.Serve(l, t.debugMux())
+}
+
+// ListenAndServeUnix calls ListenAndServeUnix on the default Tracer.
+func ListenAndServeUnix(path string) error {
+	return std.ListenAndServeUnix(path)
+}
+
+// debugMux returns a mux with the handlers served by ListenAndServeUnix.
+func (t *Tracer) debugMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/", t.Handler())
+	mux.Handle("/api", t.APIHandler())
+	mux.Handle("/history", t.HistoryHandler())
+	mux.Handle("/anomalies", t.AnomalyHandler(0))
+	mux.Handle("/events", t.SSEHandler())
+	return mux
+}
@@ -0,0 +1,63 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenAndServeUnix(t *testing.T) {
+	tr := NewTracer()
+	tr.Enter("/a", nil)
+	path := filepath.Join(t.TempDir(), "statetrc.sock")
+	errc := make(chan error, 1)
+	go func() { errc <- tr.ListenAndServeUnix(path) }()
+
+	var fi os.FileInfo
+	for {
+		var err error
+		if fi, err = os.Lstat(path); err == nil {
+			break
+		}
+		select {
+		case err := <-errc:
+			t.Fatal(err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if mode := fi.Mode(); mode&os.ModeSocket == 0 || mode.Perm() != 0600 {
+		t.Errorf("socket mode = %v, want a socket with 0600", mode)
+	}
+	if l, _ := os.ReadDir(filepath.Dir(path)); len(l) != 1 {
+		t.Errorf("socket directory has %d files, want only the socket", len(l))
+	}
+
+	c := http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	for _, target := range []string{"/", "/api", "/history", "/anomalies"} {
+		resp, err := c.Get("http://localhost" + target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s returned %s", target, resp.Status)
+		}
+		if target == "/" && !strings.Contains(string(body), "/a") {
+			t.Errorf("%s returned %q", target, body)
+		}
+	}
+}
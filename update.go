@@ -0,0 +1,127 @@
+package statetrc
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned when an operation refers to an entry that does not exist.
+	ErrNotFound = errors.New("statetrc: entry not found")
+	// ErrExists is returned when an operation would replace an entry that already exists.
+	ErrExists = errors.New("statetrc: entry already exists")
+)
+
+// Update replaces the properties of the existing entry with the specified id, keeping its
+// original Time. It does nothing if there is no such entry.
+func (t *Tracer) Update(id string, props interface{}) {
+	t.UpdateFunc(id, func(interface{}) interface{} { return props })
+}
+
+// Update calls Update on the default Tracer.
+func Update(id string, props interface{}) {
+	std.Update(id, props)
+}
+
+// UpdateFunc replaces the properties of the existing entry with the specified id with the
+// result of calling fn with the current properties. The entry's Time is kept. fn is called
+// with the Tracer locked, so it must not call methods on the Tracer. UpdateFunc does nothing
+// if there is no such entry.
+func (t *Tracer) UpdateFunc(id string, fn func(old interface{}) interface{}) {
+	if compiledOut {
+		return
+	}
+	id = t.full(id)
+	if t.off(id) {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	e, ok := t.entries[id]
+	if !ok {
+		return
+	}
+	e.Props = fn(e.Props)
+	t.entries[id] = e
+	t.emitLocked(UpdateEvent, e, t.nowLocked())
+}
+
+// UpdateFunc calls UpdateFunc on the default Tracer.
+func UpdateFunc(id string, fn func(old interface{}) interface{}) {
+	std.UpdateFunc(id, fn)
+}
+
+// Touch sets the Time of the existing entry with the specified id to the current time, keeping its properties.
+// In Multi mode the Time of every instance is set.
+// This is useful for heartbeat-style states where the time since the last progress is more
+// interesting than the total time in the state. Touch does nothing if there is no such entry.
+func (t *Tracer) Touch(id string) {
+	if compiledOut {
+		return
+	}
+	id = t.full(id)
+	if t.off(id) {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	e, ok := t.entries[id]
+	if !ok {
+		return
+	}
+	e.Time = t.nowLocked()
+	t.entries[id] = e
+	for i := range t.instances[id] {
+		t.instances[id][i].time = e.Time
+	}
+	t.emitLocked(UpdateEvent, e, e.Time)
+}
+
+// Touch calls Touch on the default Tracer.
+func Touch(id string) {
+	std.Touch(id)
+}
+
+// Rename changes the id of the existing entry oldID to newID, keeping its Time and properties.
+// This is useful when an operation only learns its final identity after it has started.
+// It returns an error wrapping ErrNotFound if oldID does not exist, or ErrExists if newID already exists.
+func (t *Tracer) Rename(oldID, newID string) error {
+	return t.rename(t.full(oldID), t.full(newID))
+}
+
+// Rename calls Rename on the default Tracer.
+func Rename(oldID, newID string) error {
+	return std.Rename(oldID, newID)
+}
+
+// rename implements Rename for full ids.
+func (t *Tracer) rename(oldID, newID string) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.expireLocked()
+	e, ok := t.entries[oldID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, oldID)
+	}
+	if oldID == newID {
+		return nil
+	}
+	if _, ok := t.entries[newID]; ok {
+		return fmt.Errorf("%w: %s", ErrExists, newID)
+	}
+	l, hasInstances := t.instances[oldID]
+	t.deleteLocked(oldID, false)
+	e.Id = newID
+	e.rt = nil
+	t.startTaskLocked(&e)
+	t.entries[newID] = e
+	if hasInstances {
+		t.instances[newID] = l
+	}
+	now := t.nowLocked()
+	t.countActiveLocked(newID, 1, now)
+	t.emitLocked(EnterEvent, e, now)
+	return nil
+}
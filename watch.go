@@ -0,0 +1,103 @@
+package statetrc
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventType is the kind of change an Event reports.
+type EventType int
+
+const (
+	// EnterEvent reports that an entry was entered, or renamed to a new id.
+	EnterEvent EventType = iota
+	// LeaveEvent reports that an entry was left, expired or otherwise removed. If the entry
+	// still exists because its Count was decremented, its EndTime is zero.
+	LeaveEvent
+	// UpdateEvent reports that the properties or Time of an entry were changed.
+	UpdateEvent
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EnterEvent:
+		return "enter"
+	case LeaveEvent:
+		return "leave"
+	case UpdateEvent:
+		return "update"
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event reports a change to the entries of a Tracer.
+type Event struct {
+	Type EventType
+	// Entry after the change. For a removed entry EndTime is set to the time it was removed.
+	Entry Entry
+	// Time of the change, according to the Tracer's clock
+	Time time.Time
+}
+
+// WatchBuffer is the capacity of the channels returned by Watch.
+const WatchBuffer = 256
+
+type watcher struct {
+	prefix string
+	ch     chan Event
+}
+
+// Watch returns a channel on which events are sent for changes to entries whose ids are under
+// the path prefix, and a function that stops watching and closes the channel. This allows live
+// dashboards and alerting without polling List. See CountPrefix for how prefixes are matched.
+//
+// Events are sent without blocking the traced code, so if the receiver falls more than
+// WatchBuffer events behind, further events are dropped until it catches up.
+func (t *Tracer) Watch(prefix string) (<-chan Event, func()) {
+	w := &watcher{prefix: t.full(prefix), ch: make(chan Event, WatchBuffer)}
+
+	t.mtx.Lock()
+	t.watchers = append(t.watchers, w)
+	t.mtx.Unlock()
+
+	stopped := false
+	return w.ch, func() {
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+		if stopped {
+			return
+		}
+		stopped = true
+		for i, o := range t.watchers {
+			if o == w {
+				t.watchers = append(t.watchers[:i:i], t.watchers[i+1:]...)
+				break
+			}
+		}
+		close(w.ch)
+	}
+}
+
+// Watch calls Watch on the default Tracer.
+func Watch(prefix string) (<-chan Event, func()) {
+	return std.Watch(prefix)
+}
+
+// emitLocked sends an event to the watchers interested in e and records it in the event log.
+// t.mtx must be held.
+func (c *core) emitLocked(typ EventType, e Entry, at time.Time) {
+	if len(c.watchers) == 0 && len(c.events.buf) == 0 {
+		return
+	}
+	ev := Event{Type: typ, Entry: c.redactLocked(e), Time: at}
+	c.events.add(ev)
+	for _, w := range c.watchers {
+		if !hasPathPrefix(e.Id, w.prefix) {
+			continue
+		}
+		select {
+		case w.ch <- ev:
+		default:
+		}
+	}
+}
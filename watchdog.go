@@ -0,0 +1,63 @@
+package statetrc
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultInterval is the interval used by the functions that check the entries periodically
+// when they are passed an interval of zero or less.
+const defaultInterval = 10 * time.Second
+
+// StartWatchdog starts a goroutine that captures the entries older than interval every interval
+// and, if there are any, passes them to report, oldest first. This surfaces stuck states
+// automatically for users who only look at the entries after things break. If report is nil
+// the entries are written to the standard logger. If interval is zero or less 10 seconds is
+// used. It returns a function that stops the watchdog.
+func (t *Tracer) StartWatchdog(interval time.Duration, report func(s Snapshot)) func() {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	if report == nil {
+		report = func(s Snapshot) {
+			var b strings.Builder
+			s.Render(&b, TextFormatter{})
+			log.Printf("statetrc: %d entries older than %v:\n%s", len(s.Entries), interval, b.String())
+		}
+	}
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			var now time.Time
+			s := t.snapshot(func(e *Entry) bool {
+				if now.IsZero() {
+					now = t.nowLocked()
+				}
+				return e.Age(now) > interval
+			}, ByAge)
+			if len(s.Entries) > 0 {
+				report(s)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// StartWatchdog calls StartWatchdog on the default Tracer.
+func StartWatchdog(interval time.Duration, report func(s Snapshot)) func() {
+	return std.StartWatchdog(interval, report)
+}
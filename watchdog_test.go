@@ -0,0 +1,88 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartWatchdog(t *testing.T) {
+	tr := NewTracer()
+	tr.Enter("/stuck", nil)
+	reports := make(chan Snapshot, 1)
+	stop := tr.StartWatchdog(10*time.Millisecond, func(s Snapshot) {
+		select {
+		case reports <- s:
+		default:
+		}
+	})
+	defer stop()
+
+	select {
+	case s := <-reports:
+		if got := ids(s.Entries); len(got) != 1 || got[0] != "/stuck" {
+			t.Errorf("reported %v, want [/stuck]", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no report")
+	}
+}
+
+func TestDefaultIntervals(t *testing.T) {
+	tr := NewTracer()
+	for _, interval := range []time.Duration{0, -time.Second} {
+		tr.StartWatchdog(interval, nil)()
+		tr.StartAnomalyDetector(interval, DefaultAnomalyFactor, nil)()
+		_, stop := tr.DetectStalls(interval, 1)
+		stop()
+	}
+}
+
+func TestDetectStalls(t *testing.T) {
+	tr := NewTracer()
+	tr.Enter("/stuck", nil)
+	ch, stop := tr.DetectStalls(10*time.Millisecond, 2)
+	select {
+	case e := <-ch:
+		if e.Id != "/stuck" {
+			t.Errorf("got %s, want /stuck", e.Id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no stall detected")
+	}
+	stop()
+	for range ch {
+	}
+}
+
+func TestAnomalies(t *testing.T) {
+	tr, clock := newTestTracer()
+	tr.SetStatsDepth(1)
+	for i := 0; i < anomalyMinCount; i++ {
+		completeAfter(tr, clock, "/job/done", time.Second)
+	}
+	tr.Enter("/job/slow", nil)
+	tr.Enter("/other", nil)
+	clock.Add(10 * time.Second)
+
+	tests := []struct {
+		factor float64
+		want   []string
+	}{
+		{3, []string{"/job/slow"}},
+		{20, []string{}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, a := range tr.Anomalies(tt.factor) {
+			got = append(got, a.Entry.Id)
+			if a.Group != "/job" || a.Usual != time.Second || a.Age != 10*time.Second {
+				t.Errorf("got %+v", a)
+			}
+		}
+		if len(got) != len(tt.want) || len(got) > 0 && got[0] != tt.want[0] {
+			t.Errorf("Anomalies(%v) = %v, want %v", tt.factor, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package statetrc
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebSocketHandler returns an http.Handler that pushes changes to the entries to WebSocket
+// clients, for live dashboards. Each Event is sent as a text message with its JSON encoding.
+//
+// Clients choose the entries they receive by subscribing to path prefixes, initially those given
+// by prefix parameters in the URL, or all entries if there are none. A client changes its
+// subscriptions by sending text messages of the form
+//
+//	{"subscribe": "/conn"}
+//	{"unsubscribe": "/conn"}
+//
+// After a subscription the server sends a message of the form
+//
+//	{"type": "snapshot", "prefix": "/conn", "snapshot": {...}}
+//
+// with the JSON encoding of a Snapshot of the entries under the prefix. Invalid messages are
+// answered with {"type": "error", "error": "..."}. As for Watch, events are dropped if the client
+// falls too far behind.
+//
+// So that web pages from other sites cannot read the entries through the browsers of those who
+// visit them, handshakes whose Origin header names a host other than that of the request are
+// refused, unless the origin is one of allowedOrigins, such as "https://dash.example.com".
+// Handshakes without an Origin header, which are not made by browsers, are accepted.
+func (t *Tracer) WebSocketHandler(allowedOrigins ...string) http.Handler {
+	return t.authorize(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgradeWebSocket(w, r, allowedOrigins)
+		if err != nil {
+			return
+		}
+		defer ws.conn.Close()
+
+		ch, stop := t.Watch("")
+		defer stop()
+
+		prefixes := r.URL.Query()["prefix"]
+		if len(prefixes) == 0 {
+			prefixes = []string{""}
+		}
+		subs := map[string]bool{}
+		subscribe := func(p string) error {
+			subs[p] = true
+			return ws.writeJSON(wsSnapshot{Type: "snapshot", Prefix: p, Snapshot: t.runQuery(&query{prefix: p})})
+		}
+		for _, p := range prefixes {
+			if subscribe(p) != nil {
+				return
+			}
+		}
+
+		msgs := make(chan []byte)
+		done := make(chan struct{})
+		quit := make(chan struct{})
+		defer close(quit)
+		go func() {
+			defer close(done)
+			for {
+				msg, err := ws.readMessage()
+				if err != nil {
+					return
+				}
+				select {
+				case msgs <- msg:
+				case <-quit:
+					return
+				}
+			}
+		}()
+
+		for {
+			var err error
+			select {
+			case <-done:
+				return
+			case ev, ok := <-ch:
+				if !ok {
+					return
+				}
+				for p := range subs {
+					if hasPathPrefix(ev.Entry.Id, t.full(p)) {
+						err = ws.writeJSON(ev)
+						break
+					}
+				}
+			case msg := <-msgs:
+				var req struct {
+					Subscribe   *string `json:"subscribe"`
+					Unsubscribe *string `json:"unsubscribe"`
+				}
+				switch {
+				case json.Unmarshal(msg, &req) != nil:
+					err = ws.writeJSON(wsError{Type: "error", Error: "invalid JSON message"})
+				case req.Subscribe != nil:
+					err = subscribe(*req.Subscribe)
+				case req.Unsubscribe != nil:
+					delete(subs, *req.Unsubscribe)
+				default:
+					err = ws.writeJSON(wsError{Type: "error", Error: "expected subscribe or unsubscribe"})
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	})
+}
+
+// WebSocketHandler calls WebSocketHandler on the default Tracer.
+func WebSocketHandler(allowedOrigins ...string) http.Handler {
+	return std.WebSocketHandler(allowedOrigins...)
+}
+
+type wsSnapshot struct {
+	Type     string   `json:"type"`
+	Prefix   string   `json:"prefix"`
+	Snapshot Snapshot `json:"snapshot"`
+}
+
+type wsError struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+// The WebSocket protocol is implemented here, following RFC 6455, so that the package does not
+// depend on a WebSocket library. Only what the handler needs is supported: the server side,
+// unfragmented writes and no extensions.
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes.
+const (
+	wsContinuation = 0
+	wsText         = 1
+	wsBinary       = 2
+	wsClose        = 8
+	wsPing         = 9
+	wsPong         = 10
+)
+
+// wsMaxMessage is the size of the largest message read from a client.
+const wsMaxMessage = 64 << 10
+
+// wsWriteTimeout limits how long a write to a client may block.
+const wsWriteTimeout = 10 * time.Second
+
+var errWSClosed = errors.New("statetrc: websocket closed")
+
+// wsConn is the server side of a WebSocket connection.
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	// mtx serializes writes, which are made both by the handler and by the reader
+	// replying to control frames.
+	mtx sync.Mutex
+	bw  *bufio.Writer
+}
+
+// upgradeWebSocket performs the opening handshake for r, accepting the origins allowed as
+// described for WebSocketHandler. On failure it writes an error response.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, allowedOrigins []string) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || !headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		http.Error(w, "statetrc: expected a WebSocket handshake", http.StatusBadRequest)
+		return nil, errors.New("statetrc: not a WebSocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "statetrc: unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("statetrc: unsupported WebSocket version")
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && !originAllowed(origin, r.Host, allowedOrigins) {
+		http.Error(w, "statetrc: origin not allowed", http.StatusForbidden)
+		return nil, fmt.Errorf("statetrc: origin %q not allowed", origin)
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "statetrc: connection cannot be upgraded", http.StatusInternalServerError)
+		return nil, errors.New("statetrc: connection cannot be hijacked")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha1.Sum([]byte(key + wsGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(h[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, br: rw.Reader, bw: rw.Writer}, nil
+}
+
+// originAllowed reports whether a handshake with the Origin header origin for the host host is
+// accepted, which it is if the origin has the same host or is one of allowed.
+func originAllowed(origin, host string, allowed []string) bool {
+	for _, a := range allowed {
+		if strings.EqualFold(origin, a) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, host)
+}
+
+// headerContains reports whether one of the comma-separated tokens of header key is token,
+// ignoring case.
+func headerContains(h http.Header, key, token string) bool {
+	for _, v := range h.Values(key) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *wsConn) writeJSON(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(wsText, b)
+}
+
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	hdr := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		hdr = append(hdr, byte(n))
+	case n <= 0xffff:
+		hdr = append(hdr, 126)
+		hdr = binary.BigEndian.AppendUint16(hdr, uint16(n))
+	default:
+		hdr = append(hdr, 127)
+		hdr = binary.BigEndian.AppendUint64(hdr, uint64(n))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	c.bw.Write(hdr)
+	c.bw.Write(payload)
+	return c.bw.Flush()
+}
+
+// readMessage returns the payload of the next text or binary message, answering control
+// frames as it goes. It returns errWSClosed when the client closes the connection.
+func (c *wsConn) readMessage() ([]byte, error) {
+	var msg []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case wsClose:
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			c.writeFrame(wsClose, payload)
+			return nil, errWSClosed
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsText, wsBinary, wsContinuation:
+			msg = append(msg, payload...)
+			if len(msg) > wsMaxMessage {
+				return nil, errors.New("statetrc: websocket message too large")
+			}
+			if fin {
+				return msg, nil
+			}
+		default:
+			return nil, fmt.Errorf("statetrc: unknown websocket opcode %d", op)
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err = io.ReadFull(c.br, hdr[:]); err != nil {
+		return
+	}
+	fin, op = hdr[0]&0x80 != 0, hdr[0]&0x0f
+	if hdr[1]&0x80 == 0 {
+		err = errors.New("statetrc: unmasked websocket frame from client")
+		return
+	}
+
+	n := uint64(hdr[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		if _, err = io.ReadFull(c.br, b[:]); err != nil {
+			return
+		}
+		n = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err = io.ReadFull(c.br, b[:]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(b[:])
+	}
+	if n > wsMaxMessage {
+		err = errors.New("statetrc: websocket frame too large")
+		return
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return
+}
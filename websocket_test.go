@@ -0,0 +1,113 @@
+//go:build !statetrc_off
+
+package statetrc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsDial makes a WebSocket handshake with the server at addr with the Origin header origin,
+// if not empty, and returns the connection and the response.
+func wsDial(t *testing.T, addr, origin string) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	req := "GET / HTTP/1.1\r\nHost: " + addr + "\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n" +
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"
+	if origin != "" {
+		req += "Origin: " + origin + "\r\n"
+	}
+	if _, err := io.WriteString(conn, req+"\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, br, resp
+}
+
+// wsRead reads an unfragmented text message sent by the server.
+func wsRead(t *testing.T, br *bufio.Reader) map[string]interface{} {
+	var hdr [2]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := int(hdr[1] & 0x7f)
+	if n == 126 {
+		var b [2]byte
+		io.ReadFull(br, b[:])
+		n = int(binary.BigEndian.Uint16(b[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatal(err)
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		t.Fatalf("%v: %s", err, payload)
+	}
+	return msg
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	tr := NewTracer()
+	tr.Enter("/conn/1", nil)
+	srv := httptest.NewServer(tr.WebSocketHandler())
+	defer srv.Close()
+
+	_, br, resp := wsDial(t, srv.Listener.Addr().String(), "")
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake returned %s", resp.Status)
+	}
+	if msg := wsRead(t, br); msg["type"] != "snapshot" || !strings.Contains(toJSON(msg), "/conn/1") {
+		t.Errorf("first message %v, want a snapshot with /conn/1", msg)
+	}
+	tr.Enter("/conn/2", nil)
+	if msg := wsRead(t, br); !strings.Contains(toJSON(msg), "/conn/2") {
+		t.Errorf("got %v, want the event of /conn/2", msg)
+	}
+}
+
+func toJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	srv := httptest.NewServer(NewTracer().WebSocketHandler("https://dash.example.com"))
+	defer srv.Close()
+	addr := srv.Listener.Addr().String()
+
+	tests := []struct {
+		origin string
+		code   int
+	}{
+		{"", http.StatusSwitchingProtocols},
+		{"http://" + addr, http.StatusSwitchingProtocols},
+		{"https://dash.example.com", http.StatusSwitchingProtocols},
+		{"https://evil.example.com", http.StatusForbidden},
+		{"http://" + addr + ".evil.example.com", http.StatusForbidden},
+		{"null", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if _, _, resp := wsDial(t, addr, tt.origin); resp.StatusCode != tt.code {
+				t.Errorf("got %s, want %d", resp.Status, tt.code)
+			}
+		})
+	}
+}
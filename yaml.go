@@ -0,0 +1,153 @@
+package statetrc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// YAMLFormatter writes snapshots as YAML with the same fields as the JSON encoding:
+//
+//	at: 2024-05-01T10:00:00Z
+//	entries:
+//	  - id: /conn/1
+//	    start: 2024-05-01T09:59:30Z
+//	    age: 30s
+//	    age_ns: 30000000000
+//	    count: 1
+//	    seq: 1
+//	    props:
+//	      addr: 10.0.0.1:5000
+//
+// Props are converted as they are for JSON, so structs become mappings of their exported fields.
+type YAMLFormatter struct {
+	// Options controls rendering. Only MaxProps applies, as for JSONFormatter. If nil,
+	// DefaultFormatOptions are used.
+	Options *FormatOptions
+}
+
+// Format implements Formatter.
+func (f YAMLFormatter) Format(w io.Writer, s Snapshot) error {
+	o := formatOptions(f.Options)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "at: %s\n", s.At.Format(time.RFC3339Nano))
+	if len(s.Entries) == 0 {
+		bw.WriteString("entries: []\n")
+		return bw.Flush()
+	}
+	bw.WriteString("entries:\n")
+	for _, j := range toJSONEntries(s.Entries, s.At) {
+		fmt.Fprintf(bw, "  - id: %s\n", yamlString(j.Id))
+		if j.Parent != "" {
+			fmt.Fprintf(bw, "    parent: %s\n", yamlString(j.Parent))
+		}
+		fmt.Fprintf(bw, "    start: %s\n", j.Start.Format(time.RFC3339Nano))
+		if j.End != nil {
+			fmt.Fprintf(bw, "    end: %s\n", j.End.Format(time.RFC3339Nano))
+		}
+		if j.Expires != nil {
+			fmt.Fprintf(bw, "    expires: %s\n", j.Expires.Format(time.RFC3339Nano))
+		}
+		fmt.Fprintf(bw, "    age: %s\n", j.Age)
+		fmt.Fprintf(bw, "    age_ns: %d\n", j.AgeNs)
+		fmt.Fprintf(bw, "    count: %d\n", j.Count)
+		fmt.Fprintf(bw, "    seq: %d\n", j.Seq)
+		if len(j.Labels) > 0 {
+			labels := make(map[string]interface{}, len(j.Labels))
+			for k, v := range j.Labels {
+				labels[k] = v
+			}
+			bw.WriteString("    labels:")
+			writeYAML(bw, labels, "      ")
+		}
+		if len(j.Props) > 0 {
+			var props interface{}
+			if o.MaxProps > 0 && len(j.Props) > o.MaxProps {
+				props = o.props(string(j.Props))
+			} else if err := json.Unmarshal(j.Props, &props); err != nil {
+				props = string(j.Props)
+			}
+			bw.WriteString("    props:")
+			writeYAML(bw, props, "      ")
+		}
+		if j.Stack != "" {
+			fmt.Fprintf(bw, "    stack: %s\n", yamlString(j.Stack))
+		}
+	}
+	return bw.Flush()
+}
+
+// writeYAML writes the generic JSON value v following a key whose colon has already been
+// written. Nested mappings and sequences are indented with indent.
+func writeYAML(w *bufio.Writer, v interface{}, indent string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			w.WriteString(" {}\n")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		w.WriteRune('\n')
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s%s:", indent, yamlString(k))
+			writeYAML(w, v[k], indent+"  ")
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			w.WriteString(" []\n")
+			return
+		}
+		w.WriteRune('\n')
+		for _, e := range v {
+			fmt.Fprintf(w, "%s-", indent)
+			writeYAML(w, e, indent+"  ")
+		}
+	case string:
+		fmt.Fprintf(w, " %s\n", yamlString(v))
+	case float64:
+		fmt.Fprintf(w, " %s\n", strconv.FormatFloat(v, 'g', -1, 64))
+	case bool:
+		fmt.Fprintf(w, " %t\n", v)
+	case nil:
+		w.WriteString(" null\n")
+	default:
+		fmt.Fprintf(w, " %s\n", yamlString(fmt.Sprint(v)))
+	}
+}
+
+// yamlString returns s as a YAML scalar, quoting it unless it is a plain string that cannot
+// be mistaken for another type.
+func yamlString(s string) string {
+	if s == "" || !yamlPlain(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func yamlPlain(s string) bool {
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` ") || strings.HasSuffix(s, " ") {
+		return false
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return false
+		}
+	}
+	return !strings.Contains(s, ": ") && !strings.Contains(s, " #")
+}
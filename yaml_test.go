@@ -0,0 +1,56 @@
+package statetrc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestYAMLFormatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries EntrySlice
+		want    string
+	}{
+		{"empty", nil, "at: 2024-05-01T10:00:00Z\nentries: []\n"},
+		{"entries", testEntries()[:1], `at: 2024-05-01T10:00:00Z
+entries:
+  - id: /conn/1
+    start: 2024-05-01T09:59:30Z
+    age: 30s
+    age_ns: 30000000000
+    count: 1
+    seq: 1
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			s := Snapshot{At: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Entries: tt.entries}
+			if err := s.Render(&b, YAMLFormatter{}); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLQuoting(t *testing.T) {
+	var b strings.Builder
+	s := Snapshot{At: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Entries: testEntries()}
+	if err := s.Render(&b, YAMLFormatter{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"    parent: /conn/1\n",
+		"    labels:\n      peer: a\n      proto: tcp\n",
+		"      addr: 10.0.0.1:5000\n",
+		`    props: step "2"` + "\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, b.String())
+		}
+	}
+}